@@ -0,0 +1,483 @@
+package main
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	AccountUsageFileName = "account_usage.json"
+	DefaultUsername      = "voidpointer"
+	DefaultPassword      = "voidpointer"
+)
+
+//
+// counters of an account, kept across runs
+//
+type accountUsage struct {
+	Requests  int       `json:"requests"`
+	Logins    int       `json:"logins"`
+	Failures  int       `json:"failures"`
+	Throttled int       `json:"throttled"`
+	LastUsed  time.Time `json:"last_used"`
+}
+
+type poolAccount struct {
+	credential accountCredential
+	source     string
+	usage      *accountUsage
+}
+
+//
+// accounts to log in with, the next one is used when the current one is
+// rejected or throttled
+//
+type accountPool struct {
+	accounts []*poolAccount
+	current  int
+	usage    map[string]*accountUsage
+	store    credentialStore
+	lock     sync.Mutex
+}
+
+//
+// collect accounts from environment variables, config.json and the store,
+// the public account is used if none is given
+//
+func loadAccountPool(config *appConfig) (*accountPool, error) {
+	pool := &accountPool{
+		accounts: make([]*poolAccount, 0),
+		usage:    make(map[string]*accountUsage),
+	}
+
+	err := loadConfigJSON(AccountUsageFileName, &pool.usage)
+	if err != nil {
+		return nil, err
+	}
+
+	if username := os.Getenv("CNKI_USERNAME"); len(username) > 0 {
+		pool.add(accountCredential{username, os.Getenv("CNKI_PASSWORD")}, "环境变量")
+	}
+	for _, a := range config.Accounts {
+		pool.add(a, "配置文件")
+	}
+
+	pool.store, err = openCredentialStore(config.CredentialStore)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := pool.store.Load()
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range stored {
+		pool.add(a, pool.store.Name())
+	}
+
+	if len(pool.accounts) == 0 {
+		pool.add(accountCredential{DefaultUsername, DefaultPassword}, "公共账号")
+	}
+	return pool, nil
+}
+
+//
+// add an account, an existing one with the same name is kept
+//
+func (p *accountPool) add(credential accountCredential, source string) bool {
+	if len(credential.Username) == 0 {
+		return false
+	}
+	for _, a := range p.accounts {
+		if a.credential.Username == credential.Username {
+			return false
+		}
+	}
+
+	usage, ok := p.usage[credential.Username]
+	if !ok {
+		usage = &accountUsage{}
+		p.usage[credential.Username] = usage
+	}
+	p.accounts = append(p.accounts, &poolAccount{credential, source, usage})
+	return true
+}
+
+func (p *accountPool) Len() int {
+	if p == nil {
+		return 0
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return len(p.accounts)
+}
+
+func (p *accountPool) Current() *poolAccount {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.accounts[p.current]
+}
+
+//
+// switch to the next account
+//
+func (p *accountPool) Advance() *poolAccount {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.current = (p.current + 1) % len(p.accounts)
+	return p.accounts[p.current]
+}
+
+//
+// switch to an account by name
+//
+func (p *accountPool) Select(username string) bool {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for i, a := range p.accounts {
+		if a.credential.Username == username {
+			p.current = i
+			return true
+		}
+	}
+	return false
+}
+
+//
+// update counters of the current account
+//
+func (p *accountPool) Count(update func(*accountUsage)) {
+	if p == nil {
+		return
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	usage := p.accounts[p.current].usage
+	update(usage)
+	usage.LastUsed = time.Now()
+}
+
+//
+// save counters of all accounts
+//
+func (p *accountPool) Save() error {
+	if p == nil {
+		return nil
+	}
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return saveConfigJSON(AccountUsageFileName, p.usage)
+}
+
+//
+// save accounts of the store
+//
+func (p *accountPool) saveStored() error {
+	stored := make([]accountCredential, 0)
+	for _, a := range p.accounts {
+		if a.source == p.store.Name() {
+			stored = append(stored, a.credential)
+		}
+	}
+	return p.store.Save(stored)
+}
+
+//
+// add an account to the store, the password of a stored one is updated
+//
+func (p *accountPool) Store(credential accountCredential) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for _, a := range p.accounts {
+		if a.credential.Username != credential.Username {
+			continue
+		}
+		if a.source != p.store.Name() {
+			return fmt.Errorf("账号 %s 来自%s, 请在%s中修改", credential.Username, a.source, a.source)
+		}
+		a.credential.Password = credential.Password
+		return p.saveStored()
+	}
+
+	//
+	// the public account is replaced by the first one of users
+	//
+	if len(p.accounts) == 1 && p.accounts[0].credential.Username == DefaultUsername && p.accounts[0].source == "公共账号" {
+		p.accounts = p.accounts[:0]
+		p.current = 0
+	}
+
+	p.add(credential, p.store.Name())
+	return p.saveStored()
+}
+
+//
+// remove an account from the store
+//
+func (p *accountPool) Remove(username string) error {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	for i, a := range p.accounts {
+		if a.credential.Username != username {
+			continue
+		}
+		if a.source != p.store.Name() {
+			return fmt.Errorf("账号 %s 来自%s, 请在%s中删除", username, a.source, a.source)
+		}
+		if len(p.accounts) == 1 {
+			return fmt.Errorf("不能删除唯一的账号")
+		}
+
+		p.accounts = append(p.accounts[:i], p.accounts[i+1:]...)
+		if p.current > i {
+			p.current--
+		}
+		if p.current >= len(p.accounts) {
+			p.current = 0
+		}
+		return p.saveStored()
+	}
+	return fmt.Errorf("没有账号 %s", username)
+}
+
+//
+// log in with an account
+//
+func (c *CNKIDownloader) useAccount(a *poolAccount) {
+	c.username = a.credential.Username
+	c.password = a.credential.Password
+	c.access_token = ""
+	c.refresh_token = ""
+}
+
+//
+// log in with accounts of the pool in turn, starting from the current one
+//
+func (c *CNKIDownloader) Login() error {
+	if c.accounts.Len() == 0 {
+		return c.Auth()
+	}
+
+	var err error
+	for i := 0; i < c.accounts.Len(); i++ {
+		c.useAccount(c.accounts.Current())
+
+		err = c.Auth()
+		if err == nil {
+			c.logged_out = false
+			c.accounts.Count(func(u *accountUsage) { u.Logins++ })
+			c.accounts.Save()
+			return nil
+		}
+
+		c.accounts.Count(func(u *accountUsage) { u.Failures++ })
+		c.accounts.Advance()
+	}
+	c.accounts.Save()
+
+	if c.accounts.Len() > 1 {
+		return fmt.Errorf("所有账号都登录失败, 最后一个: %s", err.Error())
+	}
+	return err
+}
+
+//
+// switch to the next account when the current one is throttled, stale is
+// the token of it, nothing is done if it has been replaced
+//
+func (c *CNKIDownloader) rotateAccount(stale string) error {
+	c.token_lock.Lock()
+	defer c.token_lock.Unlock()
+
+	if c.logged_out {
+		return errLoggedOut
+	}
+	if c.access_token != stale {
+		return nil
+	}
+
+	c.accounts.Count(func(u *accountUsage) { u.Throttled++ })
+	if c.accounts.Len() < 2 {
+		return fmt.Errorf("账号 %s 被限制访问, 没有其他可用的账号", c.username)
+	}
+
+	c.accounts.Advance()
+	return c.Login()
+}
+
+func printAccounts(pool *accountPool) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	for i, a := range pool.accounts {
+		mark := " "
+		if i == pool.current {
+			mark = color.GreenString("*")
+		}
+
+		last := "N/A"
+		if !a.usage.LastUsed.IsZero() {
+			last = a.usage.LastUsed.Format("2006-01-02 15:04")
+		}
+
+		fmt.Fprintf(color.Output, "%s %s (%s) 请求:%d 登录:%d 失败:%d 限流:%d 最后使用:%s\n",
+			mark, color.WhiteString(a.credential.Username), color.YellowString(a.source),
+			a.usage.Requests, a.usage.Logins, a.usage.Failures, a.usage.Throttled, last)
+	}
+}
+
+//
+// read a password without echo, it's read as it is if stdin is not a
+// terminal, e.g. piped from a password manager
+//
+func readPassword() string {
+	restore, err := disableEcho(os.Stdin.Fd())
+	if err != nil {
+		return getInputString()
+	}
+
+	password := getInputString()
+	restore()
+	fmt.Println()
+	return password
+}
+
+//
+// read an account from the terminal
+//
+func readCredential(username string) accountCredential {
+	if len(username) == 0 {
+		fmt.Fprintf(color.Output, "$ %s", color.CyanString("用户名: "))
+		username = getInputString()
+	}
+	fmt.Fprintf(color.Output, "$ %s", color.CyanString("密码: "))
+	return accountCredential{username, readPassword()}
+}
+
+//
+// LOGIN [username]: log in with an account from the terminal and store it
+//
+func loginCommand(c *CNKIDownloader, args []string) error {
+	username := ""
+	if len(args) > 0 {
+		username = args[0]
+	}
+
+	credential := readCredential(username)
+	if len(credential.Username) == 0 {
+		return fmt.Errorf("用户名不能为空")
+	}
+
+	//
+	// check the account before it's stored
+	//
+	c.token_lock.Lock()
+	defer c.token_lock.Unlock()
+
+	c.useAccount(&poolAccount{credential: credential})
+	err := c.Auth()
+	if err != nil && c.logged_out {
+		return fmt.Errorf("登录失败: %s", err.Error())
+	} else if err != nil {
+		//
+		// go back to the accounts of the pool, the session has no token
+		// if none of them works either
+		//
+		lerr := c.Login()
+		if lerr != nil {
+			return fmt.Errorf("登录失败: %s; 恢复原账号也失败, 当前未登录: %s", err.Error(), lerr.Error())
+		}
+		return fmt.Errorf("登录失败: %s", err.Error())
+	}
+
+	c.logged_out = false
+	err = c.accounts.Store(credential)
+	if err != nil {
+		return err
+	}
+	c.accounts.Select(credential.Username)
+	c.accounts.Count(func(u *accountUsage) { u.Logins++ })
+	c.accounts.Save()
+
+	fmt.Fprintf(color.Output, "已登录 %s, 账号保存在%s\n", color.GreenString(credential.Username), c.accounts.store.Name())
+	return nil
+}
+
+//
+// 'accounts' command: list, add, remove or use accounts of the pool
+//
+func runAccountsCommand(c *CNKIDownloader, args []string) error {
+	if c.accounts == nil {
+		return fmt.Errorf("账号不可用")
+	}
+
+	sub := "list"
+	if len(args) > 0 {
+		sub, args = strings.ToLower(args[0]), args[1:]
+	}
+
+	switch sub {
+	case "list":
+		printAccounts(c.accounts)
+		return nil
+
+	case "add":
+		//
+		// the password is read from stdin, a flag would leave it in the
+		// process list and the shell history
+		//
+		if len(args) == 0 {
+			return fmt.Errorf("请指定用户名")
+		}
+
+		credential := readCredential(args[0])
+		err := c.accounts.Store(credential)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(color.Output, "已添加 %s, 账号保存在%s\n", color.GreenString(credential.Username), c.accounts.store.Name())
+		return nil
+
+	case "remove":
+		for _, username := range args {
+			err := c.accounts.Remove(username)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+
+	case "use":
+		if len(args) == 0 {
+			return fmt.Errorf("请指定用户名")
+		}
+		if !c.accounts.Select(args[0]) {
+			return fmt.Errorf("没有账号 %s", args[0])
+		}
+		if c.offline {
+			return nil
+		}
+
+		c.token_lock.Lock()
+		defer c.token_lock.Unlock()
+		return c.Login()
+	}
+
+	return fmt.Errorf("未知的子命令 %s, 可用: list add remove use", sub)
+}
+
+//
+// commands of accounts work without login
+//
+func isAccountCommand(args []string) bool {
+	if len(args) == 0 || strings.ToLower(args[0]) != "accounts" {
+		return false
+	}
+	return len(args) == 1 || strings.ToLower(args[1]) != "use"
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func TestReadPasswordFromPipe(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	stdin := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = stdin }()
+
+	w.WriteString("secret\n")
+	w.Close()
+
+	if got := readPassword(); got != "secret" {
+		t.Errorf("readPassword = %q, want secret", got)
+	}
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/fatih/color"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultAuthorPages = 10
+	MaxProfileRows     = 10
+)
+
+type countEntry struct {
+	name  string
+	count int
+}
+
+//
+// entries of a counter, largest first, limit <= 0 means all
+//
+func rankCounts(counts map[string]int, limit int) []countEntry {
+	entries := make([]countEntry, 0, len(counts))
+	for k, v := range counts {
+		entries = append(entries, countEntry{k, v})
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].count != entries[j].count {
+			return entries[i].count > entries[j].count
+		}
+		return entries[i].name < entries[j].name
+	})
+
+	if limit > 0 && len(entries) > limit {
+		entries = entries[:limit]
+	}
+	return entries
+}
+
+//
+// check if someone is one of the creators of an article
+//
+func hasCreator(a *Article, name string) bool {
+	for _, c := range a.Information.Creator {
+		if strings.EqualFold(strings.TrimSpace(c), name) {
+			return true
+		}
+	}
+	return false
+}
+
+//
+// institutions of an article, the degree-granting unit of a thesis is
+// the author's own institution
+//
+func articleInstitutions(a *Article) []string {
+	switch a.GetDatabase() {
+	case SearchDoctorPaper, SearchMasterPaper:
+		if len(a.Information.SourceName) > 0 {
+			return []string{a.Information.SourceName}
+		}
+	}
+	return a.Information.Affiliations
+}
+
+//
+// all works of an author in every database, '/data/literatures' goes last
+// since it overlaps the others
+//
+func (c *CNKIDownloader) AuthorWorks(name string, maxPages int) ([]Article, error) {
+	databases := make([]int8, 0, len(searchRangeDefs))
+	for k := range searchRangeDefs {
+		if k != SearchAllDoc {
+			databases = append(databases, k)
+		}
+	}
+	sort.Slice(databases, func(i, j int) bool { return databases[i] < databases[j] })
+	databases = append(databases, SearchAllDoc)
+
+	works := make([]Article, 0)
+	seen := make(map[string]bool)
+	failed := 0
+
+	for _, db := range databases {
+		option := &searchOption{
+			filter:  searchFilterDefs[SearchByAuthor],
+			databse: searchRangeDefs[db],
+			order:   searchOrderDefs[OrderByPublishTime],
+		}
+
+		articles, _, err := c.Harvest(name, option, maxPages)
+		if err != nil {
+			fmt.Fprintf(color.Output, "检索%s失败 %s\n", searchRangeHints[db], color.RedString(err.Error()))
+			failed++
+			continue
+		}
+
+		for i := range articles {
+			a := &articles[i]
+			if seen[a.Instance] || !hasCreator(a, name) {
+				continue
+			}
+			seen[a.Instance] = true
+			works = append(works, *a)
+		}
+	}
+
+	if failed == len(databases) {
+		return nil, fmt.Errorf("所有检索库都检索失败")
+	}
+	return works, nil
+}
+
+//
+// keep works from institutions containing org
+//
+func filterByInstitution(works []Article, org string) []Article {
+	filtered := make([]Article, 0)
+	for i := range works {
+		for _, inst := range articleInstitutions(&works[i]) {
+			if strings.Contains(inst, org) {
+				filtered = append(filtered, works[i])
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+//
+// h-index from citations of works
+//
+func hIndex(works []Article) int {
+	cites := make([]int, 0, len(works))
+	for _, a := range works {
+		cites = append(cites, a.Information.RefCount)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(cites)))
+
+	h := 0
+	for i, n := range cites {
+		if n < i+1 {
+			break
+		}
+		h = i + 1
+	}
+	return h
+}
+
+//
+// print a counter as a bar chart, labels are kept in the given order
+//
+func printBars(entries []countEntry, width int) {
+	max := 0
+	for _, e := range entries {
+		if e.count > max {
+			max = e.count
+		}
+	}
+
+	for _, e := range entries {
+		n := 0
+		if max > 0 {
+			n = (e.count*width + max - 1) / max
+		}
+		fmt.Fprintf(color.Output, "  %s %s %d\n", e.name, color.GreenString(strings.Repeat("■", n)), e.count)
+	}
+}
+
+func printRanking(title string, entries []countEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	fmt.Fprintf(color.Output, "%s:\n", color.CyanString(title))
+	for _, e := range entries {
+		fmt.Fprintf(color.Output, "  %s (%d)\n", color.WhiteString(e.name), e.count)
+	}
+}
+
+//
+// print profile of an author
+//
+func printAuthorProfile(name string, works []Article) {
+	cites := 0
+	years := make(map[string]int)
+	venues := make(map[string]int)
+	institutions := make(map[string]int)
+	coauthors := make(map[string]int)
+	databases := make(map[string]int)
+
+	for i := range works {
+		a := &works[i]
+		cites += a.Information.RefCount
+
+		if year := a.Information.GetYear(); year > 0 {
+			years[strconv.Itoa(year)]++
+		}
+		if len(a.Information.SourceName) > 0 {
+			venues[a.Information.SourceName]++
+		}
+		for _, inst := range articleInstitutions(a) {
+			institutions[inst]++
+		}
+		for _, c := range a.Information.Creator {
+			if c = strings.TrimSpace(c); len(c) > 0 && !strings.EqualFold(c, name) {
+				coauthors[c]++
+			}
+		}
+		databases[searchRangeHints[a.GetDatabase()]]++
+	}
+
+	fmt.Fprintf(color.Output, "\n作者: %s (共 %s 篇, 被引 %s 次, H指数 %s)\n", color.WhiteString(name),
+		color.GreenString("%d", len(works)), color.RedString("%d", cites), color.GreenString("%d", hIndex(works)))
+
+	dbs := make([]string, 0)
+	for _, e := range rankCounts(databases, 0) {
+		dbs = append(dbs, fmt.Sprintf("%s %d", e.name, e.count))
+	}
+	fmt.Fprintf(color.Output, "文献类型: %s\n", strings.Join(dbs, ", "))
+
+	insts := rankCounts(institutions, MaxProfileRows)
+	printRanking("机构", insts)
+	if len(insts) > 1 {
+		fmt.Fprintf(color.Output, "%s\n", color.YellowString("(涉及多个机构, 可能包含同名作者, 可使用 -org 指定机构)"))
+	}
+
+	yearly := rankCounts(years, 0)
+	sort.Slice(yearly, func(i, j int) bool { return yearly[i].name < yearly[j].name })
+	if len(yearly) > 0 {
+		fmt.Fprintf(color.Output, "%s:\n", color.CyanString("年度发文"))
+		printBars(yearly, 40)
+	}
+
+	printRanking("主要来源", rankCounts(venues, MaxProfileRows))
+	printRanking("主要合作者", rankCounts(coauthors, MaxProfileRows))
+}
+
+//
+// co-author graph of works
+//
+func coauthorGraph(works []Article) *cooccurrenceGraph {
+	g := newCooccurrenceGraph()
+	for _, a := range works {
+		g.AddGroup(a.Information.Creator)
+	}
+	return g
+}
+
+//
+// write a graph as DOT for '.dot' or '.gv' files, as GraphML otherwise
+//
+func writeGraphFile(fileName string, g *cooccurrenceGraph) error {
+	return writeOutputFile(fileName, func(w io.Writer) error {
+		switch strings.ToLower(filepath.Ext(fileName)) {
+		case ".dot", ".gv":
+			return g.WriteDOT(w)
+		}
+		return g.WriteGraphML(w)
+	})
+}
+
+//
+// 'author' command
+//
+func runAuthorCommand(c *CNKIDownloader, args []string) error {
+	fs := flag.NewFlagSet("author", flag.ContinueOnError)
+	org := fs.String("org", "", "仅统计机构名称中包含此内容的文献, 用于区分同名作者")
+	pages := fs.Int("pages", DefaultAuthorPages, "每个检索库最多获取的页数, 为0时获取全部页面")
+	graph := fs.String("graph", "", "导出合作者网络, 扩展名为 .dot 或 .gv 时为DOT格式, 否则为GraphML")
+
+	positional, err := parseCommandFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		return fmt.Errorf("请指定作者姓名")
+	}
+	name := strings.Join(positional, " ")
+
+	works, err := c.AuthorWorks(name, *pages)
+	if err != nil {
+		return err
+	}
+	if len(*org) > 0 {
+		works = filterByInstitution(works, *org)
+	}
+	if len(works) == 0 {
+		return fmt.Errorf("没有找到作者 %s 的文献", name)
+	}
+
+	printAuthorProfile(name, works)
+
+	if len(*graph) > 0 {
+		err = writeGraphFile(*graph, coauthorGraph(works))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(color.Output, "合作者网络已导出到 %s\n", color.GreenString(*graph))
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"github.com/mozillazg/go-pinyin"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+var (
+	bibtexEntryTypes map[int8]string = map[int8]string{
+		SearchJournal:     "article",
+		SearchDoctorPaper: "phdthesis",
+		SearchMasterPaper: "mastersthesis",
+		SearchConference:  "inproceedings",
+	}
+
+	//
+	// field that holds the source name of each entry type
+	//
+	bibtexSourceFields map[int8]string = map[int8]string{
+		SearchJournal:     "journal",
+		SearchDoctorPaper: "school",
+		SearchMasterPaper: "school",
+		SearchConference:  "booktitle",
+	}
+
+	bibtexEscaper = strings.NewReplacer(
+		`\`, `\textbackslash{}`,
+		`{`, `\{`,
+		`}`, `\}`,
+		`&`, `\&`,
+		`%`, `\%`,
+		`$`, `\$`,
+		`#`, `\#`,
+		`_`, `\_`,
+		`~`, `\textasciitilde{}`,
+		`^`, `\textasciicircum{}`,
+	)
+
+	//
+	// surnames read differently from the common pronunciation
+	//
+	surnamePinyin map[rune]string = map[rune]string{
+		'曾': "zeng", '单': "shan", '仇': "qiu", '区': "ou", '查': "zha",
+		'解': "xie", '朴': "piao", '乐': "yue", '盖': "ge", '缪': "miao",
+		'覃': "qin", '翟': "zhai", '召': "shao", '秘': "bi", '繁': "po",
+		'员': "yun", '种': "chong", '长': "chang",
+	}
+
+	titleStopWords map[string]bool = map[string]bool{
+		"a": true, "an": true, "the": true, "on": true, "of": true, "in": true,
+		"for": true, "and": true, "to": true, "with": true, "by": true, "at": true,
+	}
+)
+
+//
+// escape LaTeX special characters of a field
+//
+func bibtexEscape(s string) string {
+	return bibtexEscaper.Replace(strings.TrimSpace(s))
+}
+
+//
+// lower case ascii letters and digits of a string, hanzi are converted to pinyin
+//
+func toKeyPart(s string) string {
+	args := pinyin.NewArgs()
+	args.Fallback = func(r rune, a pinyin.Args) []string {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return []string{strings.ToLower(string(r))}
+		}
+		return nil
+	}
+	return strings.Join(pinyin.LazyPinyin(s, args), "")
+}
+
+//
+// pinyin of a person name, with the surname read properly
+//
+func namePinyin(name string) string {
+	runes := []rune(strings.TrimSpace(name))
+	if len(runes) > 0 {
+		if py, ok := surnamePinyin[runes[0]]; ok {
+			return py + toKeyPart(string(runes[1:]))
+		}
+	}
+	return toKeyPart(name)
+}
+
+//
+// first meaningful word of a title, first two hanzi for chinese titles
+//
+func firstTitleWord(title string) string {
+	runes := []rune(strings.TrimSpace(title))
+	if len(runes) > 0 && unicode.Is(unicode.Han, runes[0]) {
+		end := 0
+		for end < len(runes) && end < 2 && unicode.Is(unicode.Han, runes[end]) {
+			end++
+		}
+		return toKeyPart(string(runes[:end]))
+	}
+
+	for _, word := range strings.FieldsFunc(title, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		w := toKeyPart(word)
+		if len(w) > 0 && !titleStopWords[w] {
+			return w
+		}
+	}
+	return ""
+}
+
+//
+// citation key: pinyin of first author + year + first title word
+//
+func citationKey(a *Article) string {
+	author := "anonymous"
+	if len(a.Information.Creator) > 0 {
+		if s := namePinyin(a.Information.Creator[0]); len(s) > 0 {
+			author = s
+		}
+	}
+
+	key := author
+	if year := a.Information.GetYear(); year > 0 {
+		key += fmt.Sprintf("%d", year)
+	}
+	return key + firstTitleWord(a.Information.Title)
+}
+
+//
+// suffix of the n-th repeat of a citation key
+//
+func citationKeySuffix(n int) string {
+	if n <= 26 {
+		return string(rune('a' + n - 1))
+	}
+	return strconv.Itoa(n)
+}
+
+//
+// page range in BibTeX style, like 1--10
+//
+func bibtexPages(pages string) string {
+	if parts := strings.SplitN(pages, "-", 2); len(parts) == 2 && !strings.HasPrefix(parts[1], "-") {
+		return parts[0] + "--" + parts[1]
+	}
+	return pages
+}
+
+//
+// write articles as BibTeX entries
+//
+func exportBibTeX(w io.Writer, articles []Article) error {
+	used := make(map[string]int)
+
+	for i := range articles {
+		a := &articles[i]
+		db := a.GetDatabase()
+
+		entryType, ok := bibtexEntryTypes[db]
+		if !ok {
+			entryType = "misc"
+		}
+
+		//
+		// same keys get a suffix of 'a', 'b', ... in order, and numbers
+		// after 'z'
+		//
+		key := citationKey(a)
+		if n := used[key]; n > 0 {
+			used[key] = n + 1
+			key += citationKeySuffix(n)
+		} else {
+			used[key] = 1
+		}
+
+		fields := make([][2]string, 0)
+		add := func(name, value string) {
+			if len(strings.TrimSpace(value)) > 0 {
+				fields = append(fields, [2]string{name, bibtexEscape(value)})
+			}
+		}
+
+		add("title", a.Information.Title)
+		add("author", strings.Join(a.Information.Creator, " and "))
+		if field, ok := bibtexSourceFields[db]; ok {
+			add(field, a.Information.SourceName)
+		} else {
+			add("howpublished", a.Information.SourceName)
+		}
+		if year := a.Information.GetYear(); year > 0 {
+			add("year", fmt.Sprintf("%d", year))
+		}
+		if db == SearchJournal {
+			add("volume", a.Information.Volume)
+			add("number", a.Information.Issue)
+			add("pages", bibtexPages(a.Information.Pages))
+		}
+		add("abstract", a.Information.Description)
+		add("note", "CNKI: "+a.Instance)
+
+		_, err := fmt.Fprintf(w, "@%s{%s,\n", entryType, key)
+		if err != nil {
+			return err
+		}
+		for j, f := range fields {
+			sep := ","
+			if j == len(fields)-1 {
+				sep = ""
+			}
+			_, err = fmt.Fprintf(w, "  %s = {%s}%s\n", f[0], f[1], sep)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = fmt.Fprintf(w, "}\n\n")
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
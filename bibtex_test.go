@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestBibTeXJournalFields(t *testing.T) {
+	buf := new(bytes.Buffer)
+	err := exportBibTeX(buf, []Article{testJournalArticle()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, field := range []string{"volume = {41}", "number = {01}", "pages = {1--10}"} {
+		if !strings.Contains(buf.String(), field) {
+			t.Errorf("missing %s in\n%s", field, buf.String())
+		}
+	}
+}
+
+func TestBibTeXDuplicateKeys(t *testing.T) {
+	articles := make([]Article, 30)
+	for i := range articles {
+		articles[i] = testJournalArticle()
+	}
+
+	buf := new(bytes.Buffer)
+	err := exportBibTeX(buf, articles)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := regexp.MustCompile(`(?m)^@article\{([^,]+),`).FindAllStringSubmatch(buf.String(), -1)
+	if len(keys) != len(articles) {
+		t.Fatalf("%d entries, want %d", len(keys), len(articles))
+	}
+
+	seen := make(map[string]bool)
+	for i, k := range keys {
+		key := k[1]
+		if seen[key] {
+			t.Errorf("key %s repeated", key)
+		}
+		seen[key] = true
+
+		if !regexp.MustCompile(`^[A-Za-z0-9]+$`).MatchString(key) {
+			t.Errorf("entry %d has key %q", i, key)
+		}
+	}
+	if base := keys[0][1]; keys[26][1] != base+"z" || keys[27][1] != base+"27" {
+		t.Errorf("keys 26 and 27 are %s and %s", keys[26][1], keys[27][1])
+	}
+}
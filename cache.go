@@ -0,0 +1,150 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	SearchCacheDirName = "search-cache"
+	DefaultCacheTTL    = 24 * time.Hour
+)
+
+//
+// response of a search request, shared by the api client and the disk cache
+//
+type cnkiSearchResponse struct {
+	Articles    []Article `json:"store"`
+	PageSize    int       `json:"pageSize"`
+	PageIndex   int       `json:"pageIndex"`
+	PageCount   int       `json:"pageCount"`
+	RecordCount int       `json:"recordCount"`
+}
+
+//
+// a cached search response on disk
+//
+type searchCacheEntry struct {
+	Keyword  string             `json:"keyword"`
+	Filter   string             `json:"filter"`
+	Database string             `json:"database"`
+	Order    string             `json:"order"`
+	PageSize int                `json:"page_size"`
+	Page     int                `json:"page"`
+	Year     int                `json:"year,omitempty"`
+	Class    string             `json:"class,omitempty"`
+	Time     time.Time          `json:"time"`
+	Response cnkiSearchResponse `json:"response"`
+}
+
+type searchDiskCache struct {
+	dir string
+	ttl time.Duration
+}
+
+//
+// open the search cache under the configuration directory
+//
+func newSearchDiskCache(ttl time.Duration) (*searchDiskCache, error) {
+	base, err := getConfigDir()
+	if err != nil {
+		return nil, err
+	}
+
+	dir := filepath.Join(base, SearchCacheDirName)
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	return &searchDiskCache{dir: dir, ttl: ttl}, nil
+}
+
+//
+// file name of a cached page, keyed by keyword, option, page size and page
+//
+func (d *searchDiskCache) path(keyword string, option *searchOption, page int) string {
+	enc := sha1.New()
+	fmt.Fprintf(enc, "%s\x00%s\x00%s\x00%s\x00%d\x00%d", keyword, option.filter, option.databse, option.order, option.page_size, page)
+	if option.year > 0 {
+		fmt.Fprintf(enc, "\x00%d", option.year)
+	}
+	if len(option.class) > 0 {
+		fmt.Fprintf(enc, "\x00%s", option.class)
+	}
+	return filepath.Join(d.dir, hex.EncodeToString(enc.Sum(nil))+".json")
+}
+
+//
+// load a cached page, stale entries are returned only if allowStale is set
+//
+func (d *searchDiskCache) Load(keyword string, option *searchOption, page int, allowStale bool) (*cnkiSearchResponse, error) {
+	data, err := ioutil.ReadFile(d.path(keyword, option, page))
+	if err != nil {
+		return nil, err
+	}
+
+	entry := &searchCacheEntry{}
+	err = json.Unmarshal(data, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	//
+	// the hash may collide in theory, so double check the key
+	//
+	if entry.Keyword != keyword || entry.Filter != option.filter || entry.Database != option.databse ||
+		entry.Order != option.order || entry.PageSize != option.page_size || entry.Page != page ||
+		entry.Year != option.year || entry.Class != option.class {
+		return nil, fmt.Errorf("缓存不匹配")
+	}
+
+	if !allowStale && time.Since(entry.Time) > d.ttl {
+		return nil, fmt.Errorf("缓存已过期")
+	}
+
+	return &entry.Response, nil
+}
+
+//
+// save a page into cache
+//
+func (d *searchDiskCache) Save(keyword string, option *searchOption, page int, resp *cnkiSearchResponse) error {
+	if d.ttl <= 0 {
+		return nil
+	}
+
+	entry := &searchCacheEntry{
+		Keyword:  keyword,
+		Filter:   option.filter,
+		Database: option.databse,
+		Order:    option.order,
+		PageSize: option.page_size,
+		Page:     page,
+		Year:     option.year,
+		Class:    option.class,
+		Time:     time.Now(),
+		Response: *resp,
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	//
+	// write to a temporary file first, so a broken write never shadows a good entry
+	//
+	name := d.path(keyword, option, page)
+	err = ioutil.WriteFile(name+".tmp", data, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(name+".tmp", name)
+}
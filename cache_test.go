@@ -0,0 +1,77 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+//
+// move the entry of an option to the file of another, like a hash collision
+//
+func renameCacheEntry(cache *searchDiskCache, from, to *searchOption) error {
+	return os.Rename(cache.path("深度学习", from, 1), cache.path("深度学习", to, 1))
+}
+
+func TestSearchDiskCacheYear(t *testing.T) {
+	useTempConfigDir(t)
+
+	cache, err := newSearchDiskCache(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	option := &searchOption{filter: "dc:title", databse: "/data/journals", order: "cnki:citedtime", year: 2018}
+	err = cache.Save("深度学习", option, 1, &cnkiSearchResponse{PageIndex: 1, RecordCount: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := cache.Load("深度学习", option, 1, false)
+	if err != nil || resp.RecordCount != 42 {
+		t.Fatalf("Load = %v, %v", resp, err)
+	}
+
+	//
+	// an entry of another year under the same file is not taken
+	//
+	other := *option
+	other.year = 2019
+	err = cache.Save("深度学习", &other, 1, &cnkiSearchResponse{PageIndex: 1, RecordCount: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := renameCacheEntry(cache, &other, option); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.Load("深度学习", option, 1, false); err == nil {
+		t.Error("an entry of 2019 is returned for 2018")
+	}
+}
+
+func TestSearchDiskCacheClass(t *testing.T) {
+	useTempConfigDir(t)
+
+	cache, err := newSearchDiskCache(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	option := &searchOption{filter: "dc:title", databse: "/data/literatures", order: "cnki:citedtime"}
+	err = cache.Save("深度学习", option, 1, &cnkiSearchResponse{PageIndex: 1, RecordCount: 42})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//
+	// a search within a class doesn't take the results of the whole range
+	//
+	within := *option
+	within.class = "TP3"
+	if err := renameCacheEntry(cache, option, &within); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cache.Load("深度学习", &within, 1, false); err == nil {
+		t.Error("an entry without class is returned for TP3")
+	}
+}
@@ -0,0 +1,628 @@
+package main
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var (
+	//
+	// the Chinese Library Classification (5th edition), basic classes and
+	// their sub classes, the commonly used ones are more detailed
+	//
+	clcTable map[string]string = map[string]string{
+		"A":   "马克思主义、列宁主义、毛泽东思想、邓小平理论",
+		"A1":  "马克思、恩格斯著作",
+		"A2":  "列宁著作",
+		"A3":  "斯大林著作",
+		"A4":  "毛泽东著作",
+		"A49": "邓小平著作",
+		"A5":  "马克思、恩格斯、列宁、斯大林、毛泽东、邓小平著作汇编",
+		"A7":  "马克思、恩格斯、列宁、斯大林、毛泽东、邓小平生平和传记",
+		"A8":  "马克思主义、列宁主义、毛泽东思想、邓小平理论的学习和研究",
+
+		"B":   "哲学、宗教",
+		"B0":  "哲学理论",
+		"B1":  "世界哲学",
+		"B2":  "中国哲学",
+		"B3":  "亚洲哲学",
+		"B4":  "非洲哲学",
+		"B5":  "欧洲哲学",
+		"B6":  "大洋洲哲学",
+		"B7":  "美洲哲学",
+		"B80": "思维科学",
+		"B81": "逻辑学(论理学)",
+		"B82": "伦理学(道德哲学)",
+		"B83": "美学",
+		"B84": "心理学",
+		"B9":  "宗教",
+
+		"C":   "社会科学总论",
+		"C0":  "社会科学理论与方法论",
+		"C1":  "社会科学现状及发展",
+		"C2":  "社会科学机构、团体、会议",
+		"C3":  "社会科学研究方法",
+		"C4":  "社会科学教育与普及",
+		"C5":  "社会科学丛书、文集、连续性出版物",
+		"C6":  "社会科学参考工具书",
+		"C8":  "统计学",
+		"C91": "社会学",
+		"C92": "人口学",
+		"C93": "管理学",
+		"C94": "系统科学",
+		"C95": "民族学、文化人类学",
+		"C96": "人才学",
+		"C97": "劳动科学",
+
+		"D":  "政治、法律",
+		"D0": "政治学、政治理论",
+		"D1": "国际共产主义运动",
+		"D2": "中国共产党",
+		"D4": "工人、农民、青年、妇女运动与组织",
+		"D5": "世界政治",
+		"D6": "中国政治",
+		"D8": "外交、国际关系",
+		"D9": "法律",
+
+		"E":   "军事",
+		"E0":  "军事理论",
+		"E1":  "世界军事",
+		"E2":  "中国军事",
+		"E8":  "战略学、战役学、战术学",
+		"E9":  "军事技术",
+		"E99": "军事地形学、军事地理学",
+
+		"F":    "经济",
+		"F0":   "经济学",
+		"F1":   "世界各国经济概况、经济史、经济地理",
+		"F2":   "经济管理",
+		"F20":  "国民经济管理",
+		"F21":  "经济计划",
+		"F22":  "经济计算、经济数学方法",
+		"F23":  "会计",
+		"F239": "审计",
+		"F24":  "劳动经济",
+		"F25":  "物资经济",
+		"F27":  "企业经济",
+		"F28":  "基本建设经济",
+		"F29":  "城市与市政经济",
+		"F3":   "农业经济",
+		"F4":   "工业经济",
+		"F49":  "信息产业经济",
+		"F5":   "交通运输经济",
+		"F59":  "旅游经济",
+		"F6":   "邮电通信经济",
+		"F7":   "贸易经济",
+		"F8":   "财政、金融",
+		"F81":  "财政、国家财政",
+		"F82":  "货币",
+		"F83":  "金融、银行",
+		"F84":  "保险",
+
+		"G":   "文化、科学、教育、体育",
+		"G0":  "文化理论",
+		"G1":  "世界各国文化与文化事业",
+		"G2":  "信息与知识传播",
+		"G3":  "科学、科学研究",
+		"G4":  "教育",
+		"G40": "教育学",
+		"G41": "思想政治教育、德育",
+		"G42": "教学理论",
+		"G43": "电化教育",
+		"G44": "教育心理学",
+		"G45": "教师与学生",
+		"G46": "教育行政",
+		"G47": "学校管理",
+		"G51": "世界教育事业",
+		"G52": "中国教育事业",
+		"G61": "学前教育、幼儿教育",
+		"G62": "初等教育",
+		"G63": "中等教育",
+		"G64": "高等教育",
+		"G65": "师范教育、教师教育",
+		"G71": "职业技术教育",
+		"G72": "成人教育、业余教育",
+		"G75": "少数民族教育",
+		"G76": "特殊教育",
+		"G77": "社会教育",
+		"G78": "家庭教育",
+		"G79": "自学",
+		"G8":  "体育",
+
+		"H":   "语言、文字",
+		"H0":  "语言学",
+		"H1":  "汉语",
+		"H2":  "中国少数民族语言",
+		"H3":  "常用外国语",
+		"H31": "英语",
+		"H32": "法语",
+		"H33": "德语",
+		"H34": "西班牙语",
+		"H35": "俄语",
+		"H36": "日语",
+		"H37": "阿拉伯语",
+		"H4":  "汉藏语系",
+		"H5":  "阿尔泰语系",
+		"H7":  "印欧语系",
+		"H9":  "国际辅助语",
+
+		"I":  "文学",
+		"I0": "文学理论",
+		"I1": "世界文学",
+		"I2": "中国文学",
+
+		"J":   "艺术",
+		"J0":  "艺术理论",
+		"J1":  "世界各国艺术概况",
+		"J2":  "绘画",
+		"J29": "书法、篆刻",
+		"J3":  "雕塑",
+		"J4":  "摄影艺术",
+		"J5":  "工艺美术",
+		"J59": "建筑艺术",
+		"J6":  "音乐",
+		"J7":  "舞蹈",
+		"J8":  "戏剧、曲艺、杂技艺术",
+		"J9":  "电影、电视艺术",
+
+		"K":   "历史、地理",
+		"K0":  "史学理论",
+		"K1":  "世界史",
+		"K2":  "中国史",
+		"K3":  "亚洲史",
+		"K4":  "非洲史",
+		"K5":  "欧洲史",
+		"K6":  "大洋洲史",
+		"K7":  "美洲史",
+		"K81": "传记",
+		"K85": "文物考古",
+		"K89": "风俗习惯",
+		"K9":  "地理",
+
+		"N":   "自然科学总论",
+		"N0":  "自然科学理论与方法论",
+		"N1":  "自然科学现状及发展",
+		"N2":  "自然科学机构、团体、会议",
+		"N3":  "自然科学研究方法",
+		"N4":  "自然科学教育与普及",
+		"N5":  "自然科学丛书、文集、连续性出版物",
+		"N6":  "自然科学参考工具书",
+		"N8":  "自然科学调查、考察",
+		"N91": "自然研究、自然历史",
+		"N93": "非线性科学",
+		"N94": "系统科学",
+		"N99": "情报学、情报工作",
+
+		"O":   "数理科学和化学",
+		"O1":  "数学",
+		"O11": "古典数学",
+		"O12": "初等数学",
+		"O13": "高等数学",
+		"O14": "数理逻辑、数学基础",
+		"O15": "代数、数论、组合理论",
+		"O17": "数学分析",
+		"O18": "几何、拓扑",
+		"O19": "动力系统理论",
+		"O21": "概率论与数理统计",
+		"O22": "运筹学",
+		"O23": "控制论、信息论(数学理论)",
+		"O24": "计算数学",
+		"O29": "应用数学",
+		"O3":  "力学",
+		"O4":  "物理学",
+		"O41": "理论物理学",
+		"O42": "声学",
+		"O43": "光学",
+		"O44": "电磁学、电动力学",
+		"O45": "无线电物理学",
+		"O46": "真空电子学(电子物理学)",
+		"O47": "半导体物理学",
+		"O48": "固体物理学",
+		"O51": "低温物理学",
+		"O52": "高压与高温物理学",
+		"O53": "等离子体物理学",
+		"O55": "热学与物质分子运动论",
+		"O56": "分子物理学、原子物理学",
+		"O57": "原子核物理学、高能物理学",
+		"O59": "应用物理学",
+		"O6":  "化学",
+		"O61": "无机化学",
+		"O62": "有机化学",
+		"O63": "高分子化学(高聚物)",
+		"O64": "物理化学(理论化学)、化学物理学",
+		"O65": "分析化学",
+		"O69": "应用化学",
+		"O7":  "晶体学",
+
+		"P":  "天文学、地球科学",
+		"P1": "天文学",
+		"P2": "测绘学",
+		"P3": "地球物理学",
+		"P4": "大气科学(气象学)",
+		"P5": "地质学",
+		"P7": "海洋学",
+		"P9": "自然地理学",
+
+		"Q":   "生物科学",
+		"Q1":  "普通生物学",
+		"Q2":  "细胞生物学",
+		"Q3":  "遗传学",
+		"Q4":  "生理学",
+		"Q5":  "生物化学",
+		"Q6":  "生物物理学",
+		"Q7":  "分子生物学",
+		"Q81": "生物工程学(生物技术)",
+		"Q89": "环境生物学",
+		"Q91": "古生物学",
+		"Q93": "微生物学",
+		"Q94": "植物学",
+		"Q95": "动物学",
+		"Q96": "昆虫学",
+		"Q98": "人类学",
+
+		"R":   "医药、卫生",
+		"R1":  "预防医学、卫生学",
+		"R2":  "中国医学",
+		"R3":  "基础医学",
+		"R4":  "临床医学",
+		"R5":  "内科学",
+		"R6":  "外科学",
+		"R71": "妇产科学",
+		"R72": "儿科学",
+		"R73": "肿瘤学",
+		"R74": "神经病学与精神病学",
+		"R75": "皮肤病学与性病学",
+		"R76": "耳鼻咽喉科学",
+		"R77": "眼科学",
+		"R78": "口腔科学",
+		"R79": "外国民族医学",
+		"R8":  "特种医学",
+		"R9":  "药学",
+
+		"S":  "农业科学",
+		"S1": "农业基础科学",
+		"S2": "农业工程",
+		"S3": "农学(农艺学)",
+		"S4": "植物保护",
+		"S5": "农作物",
+		"S6": "园艺",
+		"S7": "林业",
+		"S8": "畜牧、动物医学、狩猎、蚕、蜂",
+		"S9": "水产、渔业",
+
+		"T":        "工业技术",
+		"TB":       "一般工业技术",
+		"TD":       "矿业工程",
+		"TE":       "石油、天然气工业",
+		"TF":       "冶金工业",
+		"TG":       "金属学与金属工艺",
+		"TH":       "机械、仪表工业",
+		"TJ":       "武器工业",
+		"TK":       "能源与动力工程",
+		"TL":       "原子能技术",
+		"TM":       "电工技术",
+		"TM1":      "电工基础理论",
+		"TM2":      "电工材料",
+		"TM3":      "电机",
+		"TM4":      "变压器、变流器及电抗器",
+		"TM5":      "电器",
+		"TM6":      "发电、发电厂",
+		"TM7":      "输配电工程、电力网及电力系统",
+		"TM8":      "高电压技术",
+		"TM9":      "独立电源技术(直接发电)",
+		"TN":       "无线电电子学、电信技术",
+		"TN0":      "一般性问题",
+		"TN1":      "真空电子技术",
+		"TN2":      "光电子技术、激光技术",
+		"TN3":      "半导体技术",
+		"TN4":      "微电子学、集成电路(IC)",
+		"TN6":      "电子元件、组件",
+		"TN7":      "基本电子电路",
+		"TN8":      "无线电设备、电信设备",
+		"TN91":     "通信",
+		"TN92":     "无线通信",
+		"TN93":     "广播",
+		"TN94":     "电视",
+		"TN95":     "雷达",
+		"TN96":     "无线电导航",
+		"TN97":     "电子对抗(干扰及抗干扰)",
+		"TN98":     "无线电、电信测量技术及仪器",
+		"TN99":     "无线电电子学的应用",
+		"TP":       "自动化技术、计算机技术",
+		"TP1":      "自动化基础理论",
+		"TP11":     "自动化系统理论",
+		"TP13":     "自动控制理论",
+		"TP14":     "自动信息理论",
+		"TP15":     "自动模拟理论(自动仿真理论)",
+		"TP17":     "开关电路理论",
+		"TP18":     "人工智能理论",
+		"TP2":      "自动化技术及设备",
+		"TP20":     "一般性问题",
+		"TP21":     "自动化元件、部件",
+		"TP23":     "自动化装置与设备",
+		"TP24":     "机器人技术",
+		"TP27":     "自动化系统",
+		"TP29":     "自动化技术在各方面的应用",
+		"TP3":      "计算技术、计算机技术",
+		"TP30":     "一般性问题",
+		"TP31":     "计算机软件",
+		"TP311":    "程序设计、软件工程",
+		"TP312":    "程序语言、算法语言",
+		"TP313":    "汇编程序",
+		"TP314":    "编译程序、解释程序",
+		"TP315":    "管理程序、管理系统",
+		"TP316":    "操作系统",
+		"TP317":    "应用软件(程序包)",
+		"TP319":    "专用应用软件",
+		"TP32":     "一般计算器和计算机",
+		"TP33":     "电子数字计算机(不连续作用电子计算机)",
+		"TP34":     "电子模拟计算机(连续作用电子计算机)",
+		"TP35":     "混合电子计算机",
+		"TP36":     "微型计算机",
+		"TP37":     "多媒体技术与多媒体计算机",
+		"TP38":     "其他计算机",
+		"TP39":     "计算机的应用",
+		"TP391":    "信息处理(信息加工)",
+		"TP391.1":  "文字信息处理",
+		"TP391.4":  "模式识别与装置",
+		"TP391.41": "图像识别及其装置",
+		"TP391.42": "声音识别及其装置",
+		"TP391.43": "文字识别及其装置",
+		"TP391.7":  "机器辅助技术",
+		"TP391.9":  "计算机仿真",
+		"TP392":    "各种专用数据库",
+		"TP393":    "计算机网络",
+		"TP393.0":  "一般性问题",
+		"TP393.08": "网络安全",
+		"TP393.09": "计算机网络应用程序",
+		"TP393.1":  "局部网(LAN)、城域网(MAN)",
+		"TP393.2":  "广域网(WAN)",
+		"TP393.4":  "国际互联网",
+		"TP399":    "在其他方面的应用",
+		"TP6":      "射流技术(流控技术)",
+		"TP7":      "遥感技术",
+		"TP8":      "远动技术",
+		"TQ":       "化学工业",
+		"TS":       "轻工业、手工业、生活服务业",
+		"TU":       "建筑科学",
+		"TU1":      "建筑基础科学",
+		"TU2":      "建筑设计",
+		"TU3":      "建筑结构",
+		"TU4":      "土力学、地基基础工程",
+		"TU5":      "建筑材料",
+		"TU6":      "建筑施工机械和设备",
+		"TU7":      "建筑施工",
+		"TU8":      "房屋建筑设备",
+		"TU9":      "地下建筑",
+		"TU98":     "区域规划、城乡规划",
+		"TU99":     "市政工程",
+		"TV":       "水利工程",
+
+		"U":  "交通运输",
+		"U1": "综合运输",
+		"U2": "铁路运输",
+		"U4": "公路运输",
+		"U6": "水路运输",
+		"U8": "航空运输",
+
+		"V":  "航空、航天",
+		"V1": "航空、航天技术的研究与探索",
+		"V2": "航空",
+		"V4": "航天(宇宙航行)",
+		"V7": "航空、航天医学",
+
+		"X":  "环境科学、安全科学",
+		"X1": "环境科学基础理论",
+		"X2": "社会与环境",
+		"X3": "环境保护管理",
+		"X4": "灾害及其防治",
+		"X5": "环境污染及其防治",
+		"X7": "行业污染、废物处理与综合利用",
+		"X8": "环境质量评价与环境监测",
+		"X9": "安全科学",
+
+		"Z":  "综合性图书",
+		"Z1": "丛书",
+		"Z2": "百科全书、类书",
+		"Z3": "辞典",
+		"Z4": "论文集、全集、选集、杂著",
+		"Z5": "年鉴、年刊",
+		"Z6": "期刊、连续性出版物",
+		"Z8": "图书目录、文摘、索引",
+	}
+)
+
+type clcClass struct {
+	code string
+	name string
+}
+
+//
+// split a classify code field into codes, e.g. 'TP391.41;TP18'
+//
+func splitCLCCodes(s string) []string {
+	codes := make([]string, 0)
+	for _, code := range strings.FieldsFunc(s, func(r rune) bool {
+		return strings.ContainsRune(";；,，", r) || unicode.IsSpace(r)
+	}) {
+		if code = strings.ToUpper(strings.TrimSpace(code)); len(code) > 0 {
+			codes = append(codes, code)
+		}
+	}
+	return codes
+}
+
+//
+// basic class of a code, e.g. 'T' for 'TP391'
+//
+func clcTopClass(code string) string {
+	if len(code) == 0 {
+		return ""
+	}
+	return strings.ToUpper(code[:1])
+}
+
+//
+// known classes a code belongs to, from the basic class down to the code
+// itself, e.g. T, TP, TP3, TP39, TP391 for 'TP391'
+//
+func clcHierarchy(code string) []clcClass {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if i := strings.IndexAny(code, "-=/"); i > 0 {
+		code = code[:i]
+	}
+
+	classes := make([]clcClass, 0)
+	for i := 1; i <= len(code); i++ {
+		prefix := code[:i]
+		if name, ok := clcTable[prefix]; ok && !strings.HasSuffix(prefix, ".") {
+			classes = append(classes, clcClass{prefix, name})
+		}
+	}
+	return classes
+}
+
+//
+// description of a code with its hierarchy,
+// e.g. 'TP391.41 (工业技术 > 自动化技术、计算机技术 > ...)'
+//
+func describeCLCCode(code string) string {
+	names := make([]string, 0)
+	for _, c := range clcHierarchy(code) {
+		names = append(names, c.name)
+	}
+	if len(names) == 0 {
+		return code
+	}
+	return fmt.Sprintf("%s (%s)", code, strings.Join(names, " > "))
+}
+
+//
+// class of a code at a level of the hierarchy, the deepest known one is
+// taken if the hierarchy is shorter
+//
+func clcClassAt(code string, level int) clcClass {
+	h := clcHierarchy(code)
+	if len(h) == 0 {
+		return clcClass{"N/A", ""}
+	}
+	if level < 1 {
+		level = 1
+	}
+	if level > len(h) {
+		level = len(h)
+	}
+	return h[level-1]
+}
+
+//
+// check if an article belongs to a class, given by a code prefix or
+// a part of a class name
+//
+func matchCLC(info *ArticleInfo, value string) bool {
+	value = strings.TrimSpace(value)
+	upper := strings.ToUpper(value)
+
+	for _, code := range splitCLCCodes(info.ClassifyCode) {
+		if strings.HasPrefix(code, upper) {
+			return true
+		}
+		for _, c := range clcHierarchy(code) {
+			if strings.Contains(c.name, value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+//
+// print a class with its parents and direct sub classes, false if it's unknown
+//
+func printCLCClass(code string) bool {
+	h := clcHierarchy(code)
+	if len(h) == 0 {
+		return false
+	}
+	for i, c := range h {
+		fmt.Fprintf(color.Output, "%s%s %s\n", strings.Repeat("  ", i), color.CyanString(c.code), color.WhiteString(c.name))
+	}
+
+	parent := h[len(h)-1].code
+	children := make([]string, 0)
+	for k := range clcTable {
+		if len(k) <= len(parent) || !strings.HasPrefix(k, parent) {
+			continue
+		}
+		if ph := clcHierarchy(k); len(ph) >= 2 && ph[len(ph)-2].code == parent {
+			children = append(children, k)
+		}
+	}
+	sort.Strings(children)
+
+	for _, k := range children {
+		fmt.Fprintf(color.Output, "%s%s %s\n", strings.Repeat("  ", len(h)), color.CyanString(k), clcTable[k])
+	}
+	return true
+}
+
+//
+// REPL command: GROUP [level], group loaded results by CLC classes
+//
+func groupCommand(c *CNKIDownloader, args []string) {
+	level := 2
+	if len(args) > 0 && len(args[0]) > 0 {
+		_, err := fmt.Sscanf(args[0], "%d", &level)
+		if err != nil || level < 1 {
+			color.Red("输入无效, 用法: GROUP [层级], 例如 GROUP 1 按基本大类分组\n")
+			return
+		}
+	}
+
+	groups := make(map[string][]articleRef)
+	names := make(map[string]string)
+	for _, ref := range c.FilteredView() {
+		added := make(map[string]bool)
+		codes := splitCLCCodes(ref.entry.Information.ClassifyCode)
+		if len(codes) == 0 {
+			codes = []string{""}
+		}
+		for _, code := range codes {
+			class := clcClassAt(code, level)
+			if added[class.code] {
+				continue
+			}
+			added[class.code] = true
+			groups[class.code] = append(groups[class.code], ref)
+			names[class.code] = class.name
+		}
+	}
+
+	keys := make([]string, 0, len(groups))
+	for k := range groups {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if (keys[i] == "N/A") != (keys[j] == "N/A") {
+			return keys[j] == "N/A"
+		}
+		if len(groups[keys[i]]) != len(groups[keys[j]]) {
+			return len(groups[keys[i]]) > len(groups[keys[j]])
+		}
+		return keys[i] < keys[j]
+	})
+
+	for _, k := range keys {
+		fmt.Fprintf(color.Output, "\n%s %s (%d)\n", color.CyanString(k), color.GreenString(names[k]), len(groups[k]))
+		for _, ref := range groups[k] {
+			fmt.Fprintf(color.Output, "  %s: %s (%s)\n",
+				color.CyanString("%d-%02d", ref.page, ref.id),
+				color.WhiteString(ref.entry.Information.Title),
+				color.YellowString("%s", ref.entry.Information.ClassifyCode))
+		}
+	}
+	fmt.Println()
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestMatchCLC(t *testing.T) {
+	info := &ArticleInfo{ClassifyCode: "TP391.41;TP183", ClassifyName: "中图分类号"}
+
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"TP3", true},
+		{"tp391", true},
+		{"TP18", true},
+		{"自动化技术", true},
+		{"TN", false},
+		{"电子技术", false},
+
+		//
+		// the column label is not a class name
+		//
+		{"分类", false},
+		{"中图分类号", false},
+	}
+
+	for _, tt := range tests {
+		if got := matchCLC(info, tt.value); got != tt.want {
+			t.Errorf("matchCLC(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+
+	if matchCLC(&ArticleInfo{ClassifyName: "中图分类号"}, "分类") {
+		t.Error("an article without codes matches a class")
+	}
+}
+
+func TestSearchWithinClass(t *testing.T) {
+	filter := ""
+	c := &CNKIDownloader{http_client: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		filter = r.URL.Query().Get("filter")
+		return textResponse(http.StatusOK, `{"pageIndex":1,"pageCount":1,"recordCount":0}`), nil
+	})}}
+
+	option := &searchOption{filter: "dc:title", databse: "/data/literatures", order: "dc:title", class: "TP3"}
+	_, err := c.Search("深度学习", option, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "dc:title eq 深度学习 and cnki:clccode eq TP3"; filter != want {
+		t.Errorf("filter = %q, want %q", filter, want)
+	}
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+var (
+	searchFilterNames map[string]int8 = map[string]int8{
+		"subject":  SearchBySubject,
+		"abstract": SearchByAbstract,
+		"author":   SearchByAuthor,
+		"keyword":  SearchByKeyword,
+		"source":   SearchBySource,
+		"class":    SearchByClass,
+	}
+
+	searchRangeNames map[string]int8 = map[string]int8{
+		"all":        SearchAllDoc,
+		"journal":    SearchJournal,
+		"doctor":     SearchDoctorPaper,
+		"master":     SearchMasterPaper,
+		"conference": SearchConference,
+	}
+
+	searchOrderNames map[string]int8 = map[string]int8{
+		"subject":   OrderBySubject,
+		"cites":     OrderByRefCount,
+		"time":      OrderByPublishTime,
+		"downloads": OrderByDownloadedTime,
+	}
+)
+
+//
+// parse flags that may appear anywhere among the positional arguments
+//
+func parseCommandFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := make([]string, 0)
+	for {
+		err := fs.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	return positional, nil
+}
+
+//
+// print usage of command line mode
+//
+func printCommandUsage() {
+	fmt.Fprintf(os.Stderr, "用法: %s [选项] [命令]\n", os.Args[0])
+	fmt.Fprintf(os.Stderr, "不带命令时进入交互模式, 可用的命令:\n")
+	fmt.Fprintf(os.Stderr, "  search <keyword> [-by subject|abstract|author|keyword|source|class] [-db all|journal|doctor|master|conference]\n")
+	fmt.Fprintf(os.Stderr, "         [-order subject|cites|time|downloads] [-pages n] [-format %s] [-o file]\n", strings.Join(exportFormatNames(), "|"))
+	fmt.Fprintf(os.Stderr, "                                  检索并按指定格式输出结果\n")
+	fmt.Fprintf(os.Stderr, "  federated <keyword> [-by ...] [-order ...] [-pages n] [-format ...] [-o file]\n")
+	fmt.Fprintf(os.Stderr, "                                  同时检索期刊、博硕士论文和会议, 合并排序并去除重复\n")
+	fmt.Fprintf(os.Stderr, "  stats <keyword> [-by ...] [-db ...] [-pages n] [-format %s] [-o file]\n", strings.Join(reportFormatNames(), "|"))
+	fmt.Fprintf(os.Stderr, "                                  统计全部检索结果: 年度发文、被引和下载分布、主要来源和作者、中图分类\n")
+	fmt.Fprintf(os.Stderr, "  keywords <keyword> [-by ...] [-db ...] [-pages n] [-top n] [-min n] [-o file.json]\n")
+	fmt.Fprintf(os.Stderr, "                                  关键词共现分析, 导出VOSviewer网络\n")
+	fmt.Fprintf(os.Stderr, "  trend <keyword>... [2000-2024] [-by ...] [-db ...] [-o file.csv]\n")
+	fmt.Fprintf(os.Stderr, "                                  按年统计检索结果数量, 多个关键词并列比较\n")
+	fmt.Fprintf(os.Stderr, "  author <name> [-org text] [-pages n] [-graph file.graphml|file.dot]\n")
+	fmt.Fprintf(os.Stderr, "                                  汇总作者在各检索库的文献, 导出合作者网络\n")
+	fmt.Fprintf(os.Stderr, "  fetch <url|code|instance>...     下载CNKI网页链接、文件名代码(如JSJX201801001)或instance对应的文档\n")
+	fmt.Fprintf(os.Stderr, "  import <file> [-report file] [-no-confirm] [-no-download]\n")
+	fmt.Fprintf(os.Stderr, "                                  导入书目(每行一个标题、BibTeX、RIS或CSV), 逐条检索匹配并下载\n")
+	fmt.Fprintf(os.Stderr, "  library search <words>...       在已下载的文档中查找(无需登录)\n")
+	fmt.Fprintf(os.Stderr, "  library list [-by source|year|author] [-tag tag]\n")
+	fmt.Fprintf(os.Stderr, "                                  列出已下载的文档\n")
+	fmt.Fprintf(os.Stderr, "  library show|tag|untag|note <n|instance> ...\n")
+	fmt.Fprintf(os.Stderr, "                                  查看文档详情, 管理标签和笔记\n")
+	fmt.Fprintf(os.Stderr, "  verify [-no-repair]             检查已下载的文件, 找回移动过的文件, 重新下载缺失或损坏的文件\n")
+	fmt.Fprintf(os.Stderr, "  watch add <alias>... [-download]\n")
+	fmt.Fprintf(os.Stderr, "                                  按拼音代码(如JSJX)关注期刊, -download 检查时自动下载\n")
+	fmt.Fprintf(os.Stderr, "  watch check [alias...] [-download] [-notify] [-pages n]\n")
+	fmt.Fprintf(os.Stderr, "                                  列出关注的期刊自上次检查以来的新文章\n")
+	fmt.Fprintf(os.Stderr, "  watch list|remove <alias>...    列出或取消关注的期刊\n")
+	fmt.Fprintf(os.Stderr, "  saved list                      列出保存的检索\n")
+	fmt.Fprintf(os.Stderr, "  saved run <name> [-notify]      重新运行保存的检索, 仅报告新文章\n")
+	fmt.Fprintf(os.Stderr, "  saved delete <name>             删除保存的检索\n")
+	fmt.Fprintf(os.Stderr, "  accounts [list]                 列出账号及其使用次数(无需登录)\n")
+	fmt.Fprintf(os.Stderr, "  accounts add <username>         保存账号到系统钥匙串或加密文件(无需登录),\n")
+	fmt.Fprintf(os.Stderr, "                                  密码从终端或标准输入读取\n")
+	fmt.Fprintf(os.Stderr, "  accounts remove|use <username>  删除保存的账号, 或切换到指定账号\n")
+	fmt.Fprintf(os.Stderr, "  whoami                          显示当前账号和令牌的有效期(无需登录)\n")
+	fmt.Fprintf(os.Stderr, "  logout                          删除保存的登录令牌, 下次启动时重新登录\n")
+	fmt.Fprintf(os.Stderr, "账号也可以通过环境变量 CNKI_USERNAME/CNKI_PASSWORD 或 config.json 的 accounts 指定\n")
+	fmt.Fprintf(os.Stderr, "选项:\n")
+	flag.PrintDefaults()
+}
+
+//
+// run a command in command line mode
+//
+func runCommand(c *CNKIDownloader, args []string) error {
+	switch strings.ToLower(args[0]) {
+	case "search":
+		return runSearchCommand(c, args[1:])
+	case "fetch":
+		return runFetchCommand(c, args[1:])
+	case "author":
+		return runAuthorCommand(c, args[1:])
+	case "federated":
+		return runFederatedCommand(c, args[1:])
+	case "stats":
+		return runStatsCommand(c, args[1:])
+	case "trend":
+		return runTrendCommand(c, args[1:])
+	case "keywords":
+		return runKeywordsCommand(c, args[1:])
+	case "import":
+		return runImportCommand(c, args[1:])
+	case "saved":
+		return runSavedCommand(c, args[1:])
+	case "library":
+		return runLibraryCommand(args[1:])
+	case "verify":
+		return runVerifyCommand(c, args[1:])
+	case "watch":
+		return runWatchCommand(c, args[1:])
+	case "accounts":
+		return runAccountsCommand(c, args[1:])
+	case "logout":
+		return logoutCommand(c)
+	case "whoami":
+		return whoamiCommand(c)
+	}
+
+	printCommandUsage()
+	return fmt.Errorf("未知的命令 %s", args[0])
+}
+
+//
+// commands that work on local data only, no login is required, verify is
+// one of them if nothing is downloaded again
+//
+func isLocalCommand(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "library", "logout", "whoami":
+		return true
+	case "verify":
+		//
+		// bad flags are reported by the command without logging in
+		//
+		noRepair, err := parseVerifyFlags(args[1:], ioutil.Discard)
+		return err != nil || noRepair
+	}
+	return false
+}
+
+//
+// build a search option from names of command line
+//
+func newSearchOptionByName(filter, database, order string) (*searchOption, error) {
+	f, ok := searchFilterNames[strings.ToLower(filter)]
+	if !ok {
+		return nil, fmt.Errorf("未知的检索类型 %s", filter)
+	}
+	d, ok := searchRangeNames[strings.ToLower(database)]
+	if !ok {
+		return nil, fmt.Errorf("未知的检索库 %s", database)
+	}
+	o, ok := searchOrderNames[strings.ToLower(order)]
+	if !ok {
+		return nil, fmt.Errorf("未知的排序依据 %s", order)
+	}
+
+	return &searchOption{
+		filter:  searchFilterDefs[f],
+		databse: searchRangeDefs[d],
+		order:   searchOrderDefs[o],
+	}, nil
+}
+
+//
+// 'search' command of command line mode
+//
+func runSearchCommand(c *CNKIDownloader, args []string) error {
+	fs := flag.NewFlagSet("search", flag.ContinueOnError)
+	filter := fs.String("by", "subject", "检索类型")
+	database := fs.String("db", "all", "检索库的范围")
+	order := fs.String("order", "subject", "结果的排序依据")
+	pages := fs.Int("pages", 1, "最多获取的页数, 为0时获取全部页面")
+	format := fs.String("format", "text", "输出格式")
+	output := fs.String("o", "-", "输出文件, '-'为标准输出")
+
+	positional, err := parseCommandFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		return fmt.Errorf("请指定检索的内容")
+	}
+
+	option, err := newSearchOptionByName(*filter, *database, *order)
+	if err != nil {
+		return err
+	}
+
+	articles, _, err := c.Harvest(strings.Join(positional, " "), option, *pages)
+	if err != nil {
+		return err
+	}
+
+	return exportArticlesToFile(*output, *format, articles)
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestIsLocalCommand(t *testing.T) {
+	tests := []struct {
+		args string
+		want bool
+	}{
+		{"", false},
+		{"whoami", true},
+		{"LIBRARY list", true},
+		{"verify", false},
+		{"verify -no-repair", true},
+		{"verify -no-repair=false", false},
+		{"verify -unknown", true},
+		{"search 深度学习", false},
+	}
+
+	for _, test := range tests {
+		if got := isLocalCommand(strings.Fields(test.args)); got != test.want {
+			t.Errorf("isLocalCommand(%q) = %v, want %v", test.args, got, test.want)
+		}
+	}
+}
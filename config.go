@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+const (
+	AppConfigDirName  = "cnki-downloader"
+	AppConfigFileName = "config.json"
+)
+
+//
+// settings of config.json, command line flags take precedence
+//
+type appConfig struct {
+	PageSize        int                 `json:"page_size"`
+	ZoteroURL       string              `json:"zotero_url"`
+	Accounts        []accountCredential `json:"accounts"`
+	CredentialStore string              `json:"credential_store"`
+	Transport       transportConfig     `json:"transport"`
+}
+
+//
+// get (and create) the configuration directory of this application
+//
+func getConfigDir() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(base, AppConfigDirName)
+	err = os.MkdirAll(dir, 0700)
+	if err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+//
+// path of a file under the configuration directory
+//
+func getConfigFile(name string) (string, error) {
+	dir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, name), nil
+}
+
+//
+// read a json file under the configuration directory, a missing file is not an error
+//
+func loadConfigJSON(name string, v interface{}) error {
+	fileName, err := getConfigFile(name)
+	if err != nil {
+		return err
+	}
+
+	data, err := ioutil.ReadFile(fileName)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(data, v)
+}
+
+//
+// write a json file under the configuration directory
+//
+func saveConfigJSON(name string, v interface{}) error {
+	fileName, err := getConfigFile(name)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(v, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(fileName+".tmp", data, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(fileName+".tmp", fileName)
+}
+
+//
+// load config.json, defaults are used if it doesn't exist
+//
+func loadAppConfig() (*appConfig, error) {
+	config := &appConfig{
+		ZoteroURL: DefaultZoteroConnectorURL,
+		Transport: defaultTransportConfig(),
+	}
+	err := loadConfigJSON(AppConfigFileName, config)
+	return config, err
+}
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+const (
+	CredentialsFileName = "credentials.enc"
+	CredentialsKeyName  = "credentials.key"
+	KeyringService      = "cnki-downloader"
+	KeyringAccount      = "accounts"
+)
+
+//
+// username and password of a CNKI mobile account
+//
+type accountCredential struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+//
+// a place to keep accounts added by users
+//
+type credentialStore interface {
+	Name() string
+	Load() ([]accountCredential, error)
+	Save(accounts []accountCredential) error
+}
+
+//
+// accounts kept in the OS keyring through its command line tool, the
+// keychain on macOS and the secret service (secret-tool) on Linux
+//
+type keyringStore struct{}
+
+//
+// accounts encrypted by AES-GCM with a random key in another file of the
+// same directory, it only keeps passwords from being read at a glance, not
+// from anyone who can read the directory
+//
+type encryptedFileStore struct{}
+
+//
+// check if the keyring tool of this OS is installed
+//
+func keyringAvailable() bool {
+	tool := ""
+	switch runtime.GOOS {
+	case "darwin":
+		tool = "security"
+	case "linux":
+		tool = "secret-tool"
+	default:
+		return false
+	}
+	_, err := exec.LookPath(tool)
+	return err == nil
+}
+
+func (s *keyringStore) Name() string {
+	return "系统钥匙串"
+}
+
+func (s *keyringStore) Load() ([]accountCredential, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", KeyringService, "-a", KeyringAccount, "-w")
+	case "linux":
+		cmd = exec.Command("secret-tool", "lookup", "service", KeyringService, "account", KeyringAccount)
+	default:
+		return nil, fmt.Errorf("不支持 %s 的系统钥匙串", runtime.GOOS)
+	}
+
+	stderr := new(bytes.Buffer)
+	cmd.Stderr = stderr
+	out, err := cmd.Output()
+
+	//
+	// both tools exit with an error if nothing is stored, secret-tool
+	// says nothing while security complains the item could not be found
+	//
+	if err != nil {
+		_, exited := err.(*exec.ExitError)
+		message := strings.TrimSpace(stderr.String())
+		if exited && (len(message) == 0 || strings.Contains(message, "could not be found")) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("读取系统钥匙串失败: %s %s", err.Error(), message)
+	}
+
+	out = decodeKeychainPassword(bytes.TrimSpace(out))
+	if len(out) == 0 {
+		return nil, nil
+	}
+
+	accounts := make([]accountCredential, 0)
+	err = json.Unmarshal(out, &accounts)
+	if err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+func (s *keyringStore) Save(accounts []accountCredential) error {
+	data, err := json.Marshal(accounts)
+	if err != nil {
+		return err
+	}
+
+	//
+	// the secret is written to stdin, arguments can be seen by anyone with ps
+	//
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "-i")
+		cmd.Stdin = strings.NewReader(keychainSaveCommand(data))
+	case "linux":
+		cmd = exec.Command("secret-tool", "store", "--label=CNKI Downloader", "service", KeyringService, "account", KeyringAccount)
+		cmd.Stdin = bytes.NewReader(data)
+	default:
+		return fmt.Errorf("不支持 %s 的系统钥匙串", runtime.GOOS)
+	}
+
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("写入系统钥匙串失败: %s %s", err.Error(), strings.TrimSpace(string(out)))
+	}
+
+	//
+	// security exits with 0 in the interactive mode even if a command fails,
+	// anything but its prompt is an error message
+	//
+	message := strings.TrimSpace(strings.Replace(string(out), "security>", "", -1))
+	if runtime.GOOS == "darwin" && len(message) > 0 {
+		return fmt.Errorf("写入系统钥匙串失败: %s", message)
+	}
+	return nil
+}
+
+//
+// command of 'security -i' to store the data, it's given in hex by -X so
+// that no quoting is needed
+//
+func keychainSaveCommand(data []byte) string {
+	return fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n", KeyringService, KeyringAccount, hex.EncodeToString(data))
+}
+
+//
+// security prints the stored data in hex if it's not all printable, e.g.
+// for names in Chinese
+//
+func decodeKeychainPassword(out []byte) []byte {
+	if len(out) == 0 || out[0] == '[' {
+		return out
+	}
+	data, err := hex.DecodeString(string(out))
+	if err != nil {
+		return out
+	}
+	return data
+}
+
+func (s *encryptedFileStore) Name() string {
+	return "加密文件"
+}
+
+//
+// key of the encrypted file, created at the first time, a broken one is
+// never replaced or the stored accounts would be lost
+//
+func (s *encryptedFileStore) key() ([]byte, error) {
+	fileName, err := getConfigFile(CredentialsKeyName)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := ioutil.ReadFile(fileName)
+	if err == nil && len(key) != 32 {
+		return nil, fmt.Errorf("密钥文件 %s 已损坏, 请恢复它, 或删除它和 %s 后重新添加账号", fileName, CredentialsFileName)
+	} else if err == nil {
+		return key, nil
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	key = make([]byte, 32)
+	_, err = io.ReadFull(rand.Reader, key)
+	if err != nil {
+		return nil, err
+	}
+
+	err = ioutil.WriteFile(fileName, key, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (s *encryptedFileStore) cipher() (cipher.AEAD, error) {
+	key, err := s.key()
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (s *encryptedFileStore) Load() ([]accountCredential, error) {
+	fileName, err := getConfigFile(CredentialsFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := ioutil.ReadFile(fileName)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	aead, err := s.cipher()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < aead.NonceSize() {
+		return nil, fmt.Errorf("账号文件已损坏")
+	}
+
+	plain, err := aead.Open(nil, data[:aead.NonceSize()], data[aead.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("无法解密账号文件: %s", err.Error())
+	}
+
+	accounts := make([]accountCredential, 0)
+	err = json.Unmarshal(plain, &accounts)
+	if err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+func (s *encryptedFileStore) Save(accounts []accountCredential) error {
+	fileName, err := getConfigFile(CredentialsFileName)
+	if err != nil {
+		return err
+	}
+
+	plain, err := json.Marshal(accounts)
+	if err != nil {
+		return err
+	}
+
+	aead, err := s.cipher()
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return err
+	}
+
+	err = ioutil.WriteFile(fileName+".tmp", aead.Seal(nonce, nonce, plain, nil), 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(fileName+".tmp", fileName)
+}
+
+//
+// open the store of a kind: keyring, file or auto (keyring if it's installed)
+//
+func openCredentialStore(kind string) (credentialStore, error) {
+	switch strings.ToLower(kind) {
+	case "keyring":
+		if !keyringAvailable() {
+			return nil, fmt.Errorf("系统钥匙串不可用")
+		}
+		return &keyringStore{}, nil
+	case "file":
+		return &encryptedFileStore{}, nil
+	case "", "auto":
+		//
+		// the tool may be installed without a running keyring service
+		//
+		if keyringAvailable() {
+			keyring := &keyringStore{}
+			if _, err := keyring.Load(); err == nil {
+				return keyring, nil
+			}
+		}
+		return &encryptedFileStore{}, nil
+	}
+	return nil, fmt.Errorf("未知的账号存储方式 %s, 可用: auto keyring file", kind)
+}
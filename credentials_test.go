@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestKeychainSaveCommand(t *testing.T) {
+	data, _ := json.Marshal([]accountCredential{{"张三", `pa"ss word`}})
+	cmd := keychainSaveCommand(data)
+
+	if strings.Contains(cmd, "ss word") || strings.Contains(cmd, "张三") {
+		t.Fatalf("secret not encoded: %q", cmd)
+	}
+	if !strings.HasSuffix(cmd, "\n") || strings.Count(cmd, "\n") != 1 {
+		t.Fatalf("command is not one line: %q", cmd)
+	}
+
+	fields := strings.Fields(cmd)
+	decoded, err := hex.DecodeString(fields[len(fields)-1])
+	if err != nil || string(decoded) != string(data) {
+		t.Fatalf("decoded = %q, %v", decoded, err)
+	}
+}
+
+func TestDecodeKeychainPassword(t *testing.T) {
+	data := []byte(`[{"username":"张三","password":"x"}]`)
+
+	if got := decodeKeychainPassword(data); string(got) != string(data) {
+		t.Errorf("plain: %q", got)
+	}
+	if got := decodeKeychainPassword([]byte(hex.EncodeToString(data))); string(got) != string(data) {
+		t.Errorf("hex: %q", got)
+	}
+}
+
+func TestEncryptedFileStoreBrokenKey(t *testing.T) {
+	useTempConfigDir(t)
+
+	store := &encryptedFileStore{}
+	err := store.Save([]accountCredential{{"张三", "secret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keyFile, err := getConfigFile(CredentialsKeyName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(keyFile, key[:16], 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = store.Load(); err == nil {
+		t.Fatal("Load with a broken key succeeded")
+	}
+
+	//
+	// the broken key is kept so that it can still be restored
+	//
+	if after, _ := ioutil.ReadFile(keyFile); len(after) != 16 {
+		t.Errorf("key file replaced, %d bytes", len(after))
+	}
+}
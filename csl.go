@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+type cslName struct {
+	Family  string `json:"family,omitempty"`
+	Given   string `json:"given,omitempty"`
+	Literal string `json:"literal,omitempty"`
+}
+
+type cslDate struct {
+	DateParts [][]int `json:"date-parts,omitempty"`
+	Raw       string  `json:"raw,omitempty"`
+}
+
+type cslItem struct {
+	Id             string    `json:"id"`
+	Type           string    `json:"type"`
+	Title          string    `json:"title"`
+	Author         []cslName `json:"author,omitempty"`
+	ContainerTitle string    `json:"container-title,omitempty"`
+	Publisher      string    `json:"publisher,omitempty"`
+	Genre          string    `json:"genre,omitempty"`
+	Issued         *cslDate  `json:"issued,omitempty"`
+	Volume         string    `json:"volume,omitempty"`
+	Issue          string    `json:"issue,omitempty"`
+	Page           string    `json:"page,omitempty"`
+	Abstract       string    `json:"abstract,omitempty"`
+	Keyword        string    `json:"keyword,omitempty"`
+	Note           string    `json:"note,omitempty"`
+	Source         string    `json:"source,omitempty"`
+}
+
+var (
+	cslItemTypes map[int8]string = map[int8]string{
+		SearchJournal:     "article-journal",
+		SearchDoctorPaper: "thesis",
+		SearchMasterPaper: "thesis",
+		SearchConference:  "paper-conference",
+	}
+
+	thesisGenres map[int8]string = map[int8]string{
+		SearchDoctorPaper: "博士学位论文",
+		SearchMasterPaper: "硕士学位论文",
+	}
+
+	compoundSurnames []string = []string{
+		"欧阳", "司马", "上官", "诸葛", "东方", "皇甫", "尉迟", "公孙", "慕容", "长孙",
+		"宇文", "司徒", "夏侯", "轩辕", "令狐", "端木", "申屠", "独孤", "南宫", "西门",
+	}
+)
+
+//
+// split a chinese name into family and given name, other names are kept as a whole
+//
+func splitPersonName(name string) (family string, given string, ok bool) {
+	name = strings.TrimSpace(name)
+	if !containsHan(name) || strings.ContainsAny(name, " ·") {
+		return "", "", false
+	}
+
+	for _, s := range compoundSurnames {
+		if strings.HasPrefix(name, s) && len(name) > len(s) {
+			return s, name[len(s):], true
+		}
+	}
+
+	_, size := utf8.DecodeRuneInString(name)
+	if size == len(name) {
+		return "", "", false
+	}
+	return name[:size], name[size:], true
+}
+
+//
+// convert an article to a CSL-JSON item
+//
+func newCSLItem(a *Article) cslItem {
+	db := a.GetDatabase()
+
+	itemType, ok := cslItemTypes[db]
+	if !ok {
+		itemType = "document"
+	}
+
+	item := cslItem{
+		Id:       citationKey(a),
+		Type:     itemType,
+		Title:    a.Information.Title,
+		Volume:   a.Information.Volume,
+		Issue:    a.Information.Issue,
+		Page:     a.Information.Pages,
+		Abstract: a.Information.Description,
+		Keyword:  strings.Join(a.Information.Keywords, ", "),
+		Note:     "CNKI: " + a.Instance,
+		Source:   "CNKI",
+	}
+
+	for _, c := range a.Information.Creator {
+		if family, given, ok := splitPersonName(c); ok {
+			item.Author = append(item.Author, cslName{Family: family, Given: given})
+		} else if c = strings.TrimSpace(c); len(c) > 0 {
+			item.Author = append(item.Author, cslName{Literal: c})
+		}
+	}
+
+	switch db {
+	case SearchJournal, SearchConference:
+		item.ContainerTitle = a.Information.SourceName
+	default:
+		item.Publisher = a.Information.SourceName
+		item.Genre = thesisGenres[db]
+	}
+
+	if year := a.Information.GetYear(); year > 0 {
+		item.Issued = &cslDate{DateParts: [][]int{{year}}}
+	} else if len(a.Information.CreateTime) > 0 {
+		item.Issued = &cslDate{Raw: a.Information.CreateTime}
+	}
+
+	return item
+}
+
+//
+// write articles as a CSL-JSON array
+//
+func exportCSLJSON(w io.Writer, articles []Article) error {
+	items := make([]cslItem, 0, len(articles))
+	used := make(map[string]int)
+
+	for i := range articles {
+		item := newCSLItem(&articles[i])
+
+		//
+		// ids must be unique in a file
+		//
+		if n := used[item.Id]; n > 0 {
+			used[item.Id] = n + 1
+			item.Id += strconv.Itoa(n + 1)
+		} else {
+			used[item.Id] = 1
+		}
+		items = append(items, item)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(items)
+}
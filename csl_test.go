@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestExportCSLJSONGolden(t *testing.T) {
+	journal := testJournalArticle()
+
+	thesis := testJournalArticle()
+	thesis.Instance = "mastertheses:1018123456.nh"
+	thesis.Database = searchRangeDefs[SearchMasterPaper]
+	thesis.Information.SourceName = "清华大学"
+	thesis.Information.Creator = []string{"John Smith"}
+	thesis.Information.Keywords = nil
+	thesis.Information.Volume, thesis.Information.Issue, thesis.Information.Pages = "", "", ""
+
+	//
+	// the same article twice gets a second, unique id
+	//
+	buf := new(bytes.Buffer)
+	err := exportCSLJSON(buf, []Article{journal, journal, thesis})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	golden, err := ioutil.ReadFile(filepath.Join("testdata", "csl.golden.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(buf.Bytes(), golden) {
+		t.Errorf("CSL-JSON output differs from testdata/csl.golden.json:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"strings"
+)
+
+//
+// fetch the full record of an article and merge it into the loaded one
+//
+func (c *CNKIDownloader) LoadDetail(ref *articleRef) error {
+	full, err := c.GetArticle(ref.entry.Instance)
+	if err != nil {
+		return err
+	}
+
+	ref.entry.Information.merge(&full.Information)
+	return nil
+}
+
+//
+// print a list with numbers
+//
+func printNumberedList(title string, values []string) {
+	if len(values) == 0 {
+		return
+	}
+
+	fmt.Fprintf(color.Output, "* %s:\n", title)
+	for i, v := range values {
+		fmt.Fprintf(color.Output, "*   %s %s\n", color.CyanString("[%d]", i+1), v)
+	}
+}
+
+//
+// REPL command: DETAIL ID
+//
+func detailCommand(c *CNKIDownloader, args []string) {
+	if len(args) < 1 {
+		color.Red("输入无效")
+		return
+	}
+
+	ref, err := c.ResolveArticle(args[0])
+	if err != nil {
+		fmt.Fprintf(color.Output, "输入无效 %s\n", color.RedString(err.Error()))
+		return
+	}
+
+	err = c.LoadDetail(ref)
+	if err != nil {
+		fmt.Fprintf(color.Output, "获取详情失败 %s\n", color.RedString(err.Error()))
+		return
+	}
+
+	info := &ref.entry.Information
+
+	fmt.Println()
+	printArticle(ref)
+	fmt.Fprintf(color.Output, "*     关键词: %s\n", color.GreenString(strings.Join(info.Keywords, "; ")))
+	printNumberedList("作者单位", info.Affiliations)
+	printNumberedList("基金", info.Funds)
+	if len(info.EnglishTitle) > 0 {
+		fmt.Fprintf(color.Output, "*   英文标题: %s\n", color.WhiteString(info.EnglishTitle))
+	}
+	if len(info.EnglishAbstract) > 0 {
+		fmt.Fprintf(color.Output, "*   英文摘要: \n")
+		printWrappedText(info.EnglishAbstract)
+	}
+	printNumberedList("参考文献", info.References)
+	fmt.Println()
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	DownloadRecordFileName = "downloads.json"
+)
+
+//
+// a downloaded document
+//
+type downloadRecord struct {
+	Instance    string      `json:"instance"`
+	Title       string      `json:"title"`
+	Path        string      `json:"path"`
+	Time        time.Time   `json:"time"`
+	Size        int64       `json:"size,omitempty"`
+	Hash        string      `json:"sha256,omitempty"`
+	Database    string      `json:"database,omitempty"`
+	Information ArticleInfo `json:"information"`
+	Tags        []string    `json:"tags,omitempty"`
+	Note        string      `json:"note,omitempty"`
+}
+
+func loadDownloadRecords() (map[string]*downloadRecord, error) {
+	records := make(map[string]*downloadRecord)
+	err := loadConfigJSON(DownloadRecordFileName, &records)
+	return records, err
+}
+
+//
+// size and sha256 of a file
+//
+func fileDigest(path string) (int64, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+//
+// remember where a document has been saved
+//
+func addDownloadRecord(paper *Article, path string) error {
+	records, err := loadDownloadRecords()
+	if err != nil {
+		return err
+	}
+
+	r := &downloadRecord{
+		Instance:    paper.Instance,
+		Title:       paper.Information.Title,
+		Path:        path,
+		Time:        time.Now(),
+		Database:    paper.Database,
+		Information: paper.Information,
+	}
+
+	r.Size, r.Hash, err = fileDigest(path)
+	if err != nil {
+		return err
+	}
+
+	//
+	// tags and notes are kept when a document is downloaded again
+	//
+	if old, ok := records[paper.Instance]; ok {
+		r.Tags, r.Note = old.Tags, old.Note
+	}
+
+	records[paper.Instance] = r
+	return saveConfigJSON(DownloadRecordFileName, records)
+}
+
+//
+// article of a record, for commands working on search results
+//
+func (r *downloadRecord) article() *Article {
+	a := &Article{
+		Instance:    r.Instance,
+		Information: r.Information,
+		Database:    r.Database,
+	}
+	if len(a.Information.Title) == 0 {
+		a.Information.Title = r.Title
+	}
+	return a
+}
+
+//
+// local path of downloaded documents, only files that still exist are returned
+//
+func downloadedFiles(articles []Article) map[string]string {
+	files := make(map[string]string)
+
+	records, err := loadDownloadRecords()
+	if err != nil {
+		return files
+	}
+
+	for _, a := range articles {
+		r, ok := records[a.Instance]
+		if !ok {
+			continue
+		}
+		if _, err := os.Stat(r.Path); err == nil {
+			files[a.Instance] = r.Path
+		}
+	}
+	return files
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type endNoteRefType struct {
+	Name  string `xml:"name,attr"`
+	Value int    `xml:",chardata"`
+}
+
+type endNoteRecord struct {
+	RefType        endNoteRefType `xml:"ref-type"`
+	Authors        []string       `xml:"contributors>authors>author"`
+	Title          string         `xml:"titles>title"`
+	SecondaryTitle string         `xml:"titles>secondary-title,omitempty"`
+	Periodical     *endNoteTitle  `xml:"periodical,omitempty"`
+	Publisher      string         `xml:"publisher,omitempty"`
+	Pages          string         `xml:"pages,omitempty"`
+	Volume         string         `xml:"volume,omitempty"`
+	Number         string         `xml:"number,omitempty"`
+	Keywords       *endNoteWords  `xml:"keywords,omitempty"`
+	Dates          *endNoteDates  `xml:"dates,omitempty"`
+	Abstract       string         `xml:"abstract,omitempty"`
+	Notes          string         `xml:"notes,omitempty"`
+	URLs           *endNoteURLs   `xml:"urls,omitempty"`
+}
+
+type endNoteTitle struct {
+	FullTitle string `xml:"full-title"`
+}
+
+type endNoteWords struct {
+	Keywords []string `xml:"keyword"`
+}
+
+type endNoteDates struct {
+	Year     string           `xml:"year,omitempty"`
+	PubDates *endNotePubDates `xml:"pub-dates,omitempty"`
+}
+
+type endNotePubDates struct {
+	Date string `xml:"date"`
+}
+
+type endNoteURLs struct {
+	PDF []string `xml:"pdf-urls>url"`
+}
+
+type endNoteDocument struct {
+	XMLName xml.Name        `xml:"xml"`
+	Records []endNoteRecord `xml:"records>record"`
+}
+
+var (
+	endNoteRefTypes map[int8]endNoteRefType = map[int8]endNoteRefType{
+		SearchJournal:     {"Journal Article", 17},
+		SearchDoctorPaper: {"Thesis", 32},
+		SearchMasterPaper: {"Thesis", 32},
+		SearchConference:  {"Conference Paper", 47},
+	}
+)
+
+//
+// file:// url of a local path
+//
+func fileURL(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+
+	p := filepath.ToSlash(abs)
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+	u := &url.URL{Scheme: "file", Path: p}
+	return u.String()
+}
+
+//
+// write articles as an EndNote XML document
+//
+func exportEndNote(w io.Writer, articles []Article) error {
+	files := downloadedFiles(articles)
+	doc := &endNoteDocument{}
+
+	for i := range articles {
+		a := &articles[i]
+		db := a.GetDatabase()
+
+		refType, ok := endNoteRefTypes[db]
+		if !ok {
+			refType = endNoteRefType{"Generic", 13}
+		}
+
+		r := endNoteRecord{
+			RefType:  refType,
+			Authors:  a.Information.Creator,
+			Title:    a.Information.Title,
+			Pages:    a.Information.Pages,
+			Volume:   a.Information.Volume,
+			Number:   a.Information.Issue,
+			Abstract: a.Information.Description,
+			Notes:    "CNKI: " + a.Instance,
+		}
+
+		switch db {
+		case SearchJournal:
+			r.SecondaryTitle = a.Information.SourceName
+			if len(a.Information.SourceName) > 0 {
+				r.Periodical = &endNoteTitle{a.Information.SourceName}
+			}
+		case SearchConference:
+			r.SecondaryTitle = a.Information.SourceName
+		default:
+			r.Publisher = a.Information.SourceName
+		}
+
+		if len(a.Information.Keywords) > 0 {
+			r.Keywords = &endNoteWords{a.Information.Keywords}
+		}
+		if year := a.Information.GetYear(); year > 0 || len(a.Information.CreateTime) > 0 {
+			r.Dates = &endNoteDates{}
+			if year > 0 {
+				r.Dates.Year = strconv.Itoa(year)
+			}
+			if len(a.Information.CreateTime) > 0 {
+				r.Dates.PubDates = &endNotePubDates{a.Information.CreateTime}
+			}
+		}
+		if path, ok := files[a.Instance]; ok {
+			r.URLs = &endNoteURLs{[]string{fileURL(path)}}
+		}
+
+		doc.Records = append(doc.Records, r)
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(doc)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+//
+// writes articles into a file of some format
+//
+type articleExporter func(w io.Writer, articles []Article) error
+
+var (
+	articleExporters map[string]articleExporter = map[string]articleExporter{
+		"text":    exportText,
+		"bibtex":  exportBibTeX,
+		"gbt7714": exportGBT7714,
+		"ris":     exportRIS,
+		"endnote": exportEndNote,
+		"csljson": exportCSLJSON,
+	}
+)
+
+//
+// names of supported formats
+//
+func exportFormatNames() []string {
+	names := make([]string, 0, len(articleExporters))
+	for name := range articleExporters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//
+// write articles with the named format
+//
+func exportArticles(w io.Writer, format string, articles []Article) error {
+	exporter, ok := articleExporters[strings.ToLower(format)]
+	if !ok {
+		return fmt.Errorf("不支持的格式 %s (可用格式: %s)", format, strings.Join(exportFormatNames(), " "))
+	}
+	return exporter(w, articles)
+}
+
+//
+// write articles into a file, '-' means stdout
+//
+func exportArticlesToFile(fileName string, format string, articles []Article) error {
+	return writeOutputFile(fileName, func(w io.Writer) error {
+		return exportArticles(w, format, articles)
+	})
+}
+
+//
+// write to a file, '-' means stdout, a partly written file is removed on error
+//
+func writeOutputFile(fileName string, write func(w io.Writer) error) error {
+	if fileName == "-" {
+		return write(os.Stdout)
+	}
+
+	file, err := os.Create(fileName)
+	if err != nil {
+		return err
+	}
+
+	err = write(file)
+	if err != nil {
+		file.Close()
+		os.Remove(fileName)
+		return err
+	}
+	return file.Close()
+}
+
+//
+// choose articles of current search, nothing means the current page,
+// 'all' means every page of the query, otherwise a list of IDs
+//
+func (c *CNKIDownloader) SelectArticles(args []string) ([]Article, error) {
+	if len(args) == 0 {
+		page, err := c.CurrentPage()
+		if err != nil {
+			return nil, err
+		}
+		return page.GetPageData(), nil
+	}
+
+	if len(args) == 1 && strings.ToLower(args[0]) == "all" {
+		if c.search_cache.option == nil {
+			return nil, fmt.Errorf("无搜索结果")
+		}
+		articles, _, err := c.Harvest(c.search_cache.keyword, c.search_cache.option, 0)
+		return articles, err
+	}
+
+	articles := make([]Article, 0, len(args))
+	for _, s := range args {
+		ref, err := c.ResolveArticle(s)
+		if err != nil {
+			return nil, err
+		}
+		articles = append(articles, *ref.entry)
+	}
+	return articles, nil
+}
+
+//
+// one line per article
+//
+func exportText(w io.Writer, articles []Article) error {
+	for id, a := range articles {
+		source := a.Information.SourceName
+		if len(source) == 0 {
+			source = "N/A"
+		}
+		_, err := fmt.Fprintf(w, "%02d: %s (%s) %s\n", id+1, a.Information.Title, source, a.Instance)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//
+// REPL command: EXPORT <format> <file> [ids|all]
+//
+func exportCommand(c *CNKIDownloader, args []string) {
+	if len(args) < 2 {
+		fmt.Fprintf(color.Output, "输入无效, 用法: EXPORT 格式 文件名 [ID1 ID2...|all] (可用格式: %s)\n", strings.Join(exportFormatNames(), " "))
+		return
+	}
+
+	format, fileName := args[0], args[1]
+	if _, ok := articleExporters[strings.ToLower(format)]; !ok {
+		fmt.Fprintf(color.Output, "输入无效 %s\n", color.RedString("不支持的格式 %s", format))
+		return
+	}
+
+	articles, err := c.SelectArticles(args[2:])
+	if err != nil {
+		fmt.Fprintf(color.Output, "导出失败 %s\n", color.RedString(err.Error()))
+		return
+	}
+
+	err = exportArticlesToFile(fileName, format, articles)
+	if err != nil {
+		fmt.Fprintf(color.Output, "导出失败 %s\n", color.RedString(err.Error()))
+		return
+	}
+	fmt.Fprintf(color.Output, "已导出 (%s) 篇文档到 %s\n", color.GreenString("%d", len(articles)), color.GreenString(fileName))
+}
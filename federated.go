@@ -0,0 +1,306 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/fatih/color"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	DuplicateTitleScore   = 0.9
+	FederatedRankConstant = 60
+)
+
+var (
+	//
+	// databases of federated search, versions found in former ones are preferred
+	//
+	federatedDatabases []int8 = []int8{SearchJournal, SearchDoctorPaper, SearchMasterPaper, SearchConference}
+)
+
+//
+// a merged hit of federated search
+//
+type federatedHit struct {
+	article   Article
+	databases []int8
+	score     float64
+}
+
+//
+// names of databases a hit is found in
+//
+func (h *federatedHit) DatabaseNames() string {
+	names := make([]string, 0, len(h.databases))
+	for _, db := range h.databases {
+		names = append(names, searchRangeHints[db])
+	}
+	return strings.Join(names, "+")
+}
+
+//
+// check if two articles are versions of the same work, titles are nearly the
+// same and first authors are the same if both are known
+//
+func isDuplicateArticle(a, b *Article) bool {
+	ta, tb := normalizeTitle(a.Information.Title), normalizeTitle(b.Information.Title)
+	la, lb := len(ta), len(tb)
+	if la == 0 || lb == 0 {
+		return false
+	}
+
+	//
+	// lengths differ too much to be similar enough
+	//
+	if la > lb {
+		la, lb = lb, la
+	}
+	if float64(la)/float64(lb) < DuplicateTitleScore {
+		return false
+	}
+
+	if titleSimilarity(a.Information.Title, b.Information.Title) < DuplicateTitleScore {
+		return false
+	}
+
+	if len(a.Information.Creator) > 0 && len(b.Information.Creator) > 0 {
+		return strings.TrimSpace(a.Information.Creator[0]) == strings.TrimSpace(b.Information.Creator[0])
+	}
+	return true
+}
+
+//
+// merge ranked lists of databases, duplicates are collapsed and scored by
+// reciprocal rank fusion
+//
+func mergeFederatedResults(lists [][]Article, databases []int8) []*federatedHit {
+	hits := make([]*federatedHit, 0)
+	byInstance := make(map[string]*federatedHit)
+
+	for i, list := range lists {
+		for rank := range list {
+			a := &list[rank]
+			score := 1 / float64(FederatedRankConstant+rank+1)
+
+			hit, ok := byInstance[a.Instance]
+			if !ok {
+				for _, h := range hits {
+					if isDuplicateArticle(&h.article, a) {
+						hit = h
+						break
+					}
+				}
+			}
+
+			if hit == nil {
+				hit = &federatedHit{article: *a}
+				hits = append(hits, hit)
+			} else {
+				//
+				// the preferred version keeps its own source and authors
+				//
+				hit.article.Information.fillEmpty(&a.Information)
+			}
+			byInstance[a.Instance] = hit
+
+			found := false
+			for _, db := range hit.databases {
+				if db == databases[i] {
+					found = true
+					break
+				}
+			}
+			if !found {
+				hit.databases = append(hit.databases, databases[i])
+				hit.score += score
+			}
+		}
+	}
+
+	return hits
+}
+
+//
+// order hits by fused relevance, or by a numeric field like 'cites'
+//
+func sortFederatedHits(hits []*federatedHit, field string) {
+	sort.SliceStable(hits, func(i, j int) bool {
+		if len(field) > 0 {
+			a, b := hits[i].article.Information.numericField(field), hits[j].article.Information.numericField(field)
+			if a != b {
+				return a > b
+			}
+		}
+		return hits[i].score > hits[j].score
+	})
+}
+
+//
+// search journals, theses and conferences concurrently and merge the results
+//
+func (c *CNKIDownloader) FederatedSearch(keyword string, option *searchOption, maxPages int) ([]*federatedHit, error) {
+	lists := make([][]Article, len(federatedDatabases))
+	errs := make([]error, len(federatedDatabases))
+
+	wg := new(sync.WaitGroup)
+	for i, db := range federatedDatabases {
+		opt := *option
+		opt.databse = searchRangeDefs[db]
+
+		wg.Add(1)
+		go func(i int, opt *searchOption) {
+			defer wg.Done()
+			lists[i], _, errs[i] = c.Harvest(keyword, opt, maxPages)
+		}(i, &opt)
+	}
+	wg.Wait()
+
+	failed := 0
+	for i, err := range errs {
+		if err != nil {
+			fmt.Fprintf(color.Output, "检索%s失败 %s\n", searchRangeHints[federatedDatabases[i]], color.RedString(err.Error()))
+			failed++
+		}
+	}
+	if failed == len(federatedDatabases) {
+		return nil, fmt.Errorf("所有检索库都检索失败")
+	}
+
+	return mergeFederatedResults(lists, federatedDatabases), nil
+}
+
+func printFederatedHits(hits []*federatedHit) {
+	fmt.Fprintf(color.Output, "\n-----------------------------------------------------------(%s)--\n", color.MagentaString("联合检索"))
+	for id, h := range hits {
+		source := h.article.Information.SourceName
+		if len(source) == 0 {
+			source = "N/A"
+		}
+		fmt.Fprintf(color.Output, "%s: %s (%s) %s\n",
+			color.CyanString("%02d", id+1),
+			color.WhiteString(h.article.Information.Title),
+			color.YellowString("%s", source),
+			color.GreenString("[%s]", h.DatabaseNames()))
+	}
+	fmt.Fprintf(color.Output, "-----------------------------------------------------------(%s)--\n\n", color.MagentaString("共%d条", len(hits)))
+}
+
+//
+// plain text list of hits with databases they are found in
+//
+func writeFederatedText(w io.Writer, hits []*federatedHit) error {
+	for id, h := range hits {
+		source := h.article.Information.SourceName
+		if len(source) == 0 {
+			source = "N/A"
+		}
+		_, err := fmt.Fprintf(w, "%02d: %s (%s) [%s] %s\n", id+1, h.article.Information.Title, source, h.DatabaseNames(), h.article.Instance)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//
+// parse flags and run a federated search, hits are returned in order
+//
+func federatedSearchByArgs(c *CNKIDownloader, fs *flag.FlagSet, args []string) ([]*federatedHit, error) {
+	filter := fs.String("by", "subject", "检索类型")
+	order := fs.String("order", "subject", "排序依据, subject 为各库相关度排名的综合")
+	pages := fs.Int("pages", 1, "每个检索库最多获取的页数, 为0时获取全部页面")
+
+	positional, err := parseCommandFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(positional) == 0 {
+		return nil, fmt.Errorf("请指定检索的内容")
+	}
+
+	option, err := newSearchOptionByName(*filter, "all", *order)
+	if err != nil {
+		return nil, err
+	}
+
+	hits, err := c.FederatedSearch(strings.Join(positional, " "), option, *pages)
+	if err != nil {
+		return nil, err
+	}
+
+	switch strings.ToLower(*order) {
+	case "cites":
+		sortFederatedHits(hits, "cites")
+	case "time":
+		sortFederatedHits(hits, "year")
+	case "downloads":
+		sortFederatedHits(hits, "downloads")
+	default:
+		sortFederatedHits(hits, "")
+	}
+	return hits, nil
+}
+
+//
+// REPL command: FEDERATED <keyword> [-by ...] [-order ...] [-pages n]
+//
+func federatedCommand(c *CNKIDownloader, args []string) {
+	hits, err := federatedSearchByArgs(c, flag.NewFlagSet("federated", flag.ContinueOnError), args)
+	if err != nil {
+		fmt.Fprintf(color.Output, "联合检索失败 %s\n", color.RedString(err.Error()))
+		return
+	}
+	printFederatedHits(hits)
+	if len(hits) == 0 {
+		return
+	}
+
+	fmt.Fprintf(color.Output, "$ %s", color.CyanString("输入要下载的ID (ID1 ID2..., 直接回车返回): "))
+	for _, s := range strings.Fields(getInputString()) {
+		id, err := strconv.Atoi(s)
+		if err != nil || id < 1 || id > len(hits) {
+			fmt.Fprintf(color.Output, "无效的ID %s\n", color.RedString(s))
+			continue
+		}
+
+		a := &hits[id-1].article
+		color.White("下载中... %s\n", a.Information.Title)
+		path, err := c.Download(a)
+		if err != nil {
+			fmt.Fprintf(color.Output, "下载失败 %s\n", color.RedString(err.Error()))
+			continue
+		}
+		fmt.Fprintf(color.Output, "下载成功 (%s) \n", color.GreenString(path))
+	}
+}
+
+//
+// 'federated' command of command line mode
+//
+func runFederatedCommand(c *CNKIDownloader, args []string) error {
+	fs := flag.NewFlagSet("federated", flag.ContinueOnError)
+	format := fs.String("format", "text", "输出格式")
+	output := fs.String("o", "-", "输出文件, '-'为标准输出")
+
+	hits, err := federatedSearchByArgs(c, fs, args)
+	if err != nil {
+		return err
+	}
+
+	if strings.ToLower(*format) == "text" {
+		return writeOutputFile(*output, func(w io.Writer) error {
+			return writeFederatedText(w, hits)
+		})
+	}
+
+	articles := make([]Article, 0, len(hits))
+	for _, h := range hits {
+		articles = append(articles, h.article)
+	}
+	return exportArticlesToFile(*output, *format, articles)
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergeFederatedResultsKeepsPreferredRecord(t *testing.T) {
+	journal := Article{Instance: "journals:A", Database: searchRangeDefs[SearchJournal]}
+	journal.Information.Title = "基于深度学习的图像识别研究"
+	journal.Information.SourceName = "计算机学报"
+	journal.Information.Creator = []string{"张三", "李四"}
+	journal.Information.Issue = "03"
+
+	conference := Article{Instance: "conferences:B", Database: searchRangeDefs[SearchConference]}
+	conference.Information.Title = "基于深度学习的图像识别研究"
+	conference.Information.SourceName = "全国图像大会"
+	conference.Information.Creator = []string{"张三"}
+	conference.Information.Issue = "01"
+	conference.Information.Keywords = []string{"深度学习"}
+
+	lists := [][]Article{{journal}, {}, {}, {conference}}
+	hits := mergeFederatedResults(lists, federatedDatabases)
+	if len(hits) != 1 {
+		t.Fatalf("got %d hits, want 1", len(hits))
+	}
+
+	info := hits[0].article.Information
+	if info.SourceName != "计算机学报" || info.Issue != "03" {
+		t.Errorf("source/issue replaced by duplicate: %q %q", info.SourceName, info.Issue)
+	}
+	if !reflect.DeepEqual(info.Creator, []string{"张三", "李四"}) {
+		t.Errorf("creators replaced by duplicate: %v", info.Creator)
+	}
+	if !reflect.DeepEqual(info.Keywords, []string{"深度学习"}) {
+		t.Errorf("empty keywords not filled: %v", info.Keywords)
+	}
+	if hits[0].DatabaseNames() != "期刊+会议文献" {
+		t.Errorf("databases = %s", hits[0].DatabaseNames())
+	}
+}
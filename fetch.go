@@ -0,0 +1,175 @@
+package main
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"net/url"
+	"path"
+	"strings"
+)
+
+var (
+	//
+	// database codes of CNKI web site
+	//
+	cnkiDBCodes map[string]int8 = map[string]int8{
+		"CJFD": SearchJournal,
+		"CJFQ": SearchJournal,
+		"CAPJ": SearchJournal,
+		"CJFN": SearchJournal,
+		"CDFD": SearchDoctorPaper,
+		"CDMD": SearchDoctorPaper,
+		"CMFD": SearchMasterPaper,
+		"CPFD": SearchConference,
+		"IPFD": SearchConference,
+		"CPVD": SearchConference,
+	}
+)
+
+//
+// instance type of a database, e.g. 'journals' for /data/journals
+//
+func instanceType(database int8) string {
+	return path.Base(searchRangeDefs[database])
+}
+
+//
+// parse a web url, a filename code or an instance string into
+// instances to be tried in order
+//
+func parseFetchTarget(s string) ([]string, error) {
+	s = strings.TrimSpace(s)
+	if len(s) == 0 {
+		return nil, fmt.Errorf("内容为空")
+	}
+
+	//
+	// web url with dbcode and filename
+	//
+	if strings.Contains(s, "://") || strings.HasPrefix(strings.ToLower(s), "kns.cnki.net") {
+		if !strings.Contains(s, "://") {
+			s = "http://" + s
+		}
+
+		u, err := url.Parse(s)
+		if err != nil {
+			return nil, err
+		}
+
+		dbcode, filename := "", ""
+		for k, v := range u.Query() {
+			switch strings.ToLower(k) {
+			case "dbcode":
+				dbcode = strings.ToUpper(v[0])
+			case "filename":
+				filename = v[0]
+			}
+		}
+		if len(filename) == 0 {
+			return nil, fmt.Errorf("链接中没有 filename 参数")
+		}
+
+		if db, ok := cnkiDBCodes[dbcode]; ok {
+			return []string{instanceType(db) + ":" + filename}, nil
+		}
+		return guessInstances(filename), nil
+	}
+
+	//
+	// instance string
+	//
+	if strings.Contains(s, ":") {
+		if len(strings.Split(s, ":")) != 2 {
+			return nil, fmt.Errorf("无效的 instance 字符串 %s", s)
+		}
+		return []string{s}, nil
+	}
+
+	//
+	// filename code
+	//
+	return guessInstances(s), nil
+}
+
+//
+// possible instances of a filename code, theses are named like '1018012345.nh'
+//
+func guessInstances(filename string) []string {
+	order := []int8{SearchJournal, SearchConference, SearchDoctorPaper, SearchMasterPaper}
+	if strings.HasSuffix(strings.ToLower(filename), ".nh") {
+		order = []int8{SearchDoctorPaper, SearchMasterPaper}
+	}
+
+	instances := make([]string, 0, len(order))
+	for _, db := range order {
+		instances = append(instances, instanceType(db)+":"+filename)
+	}
+	return instances
+}
+
+//
+// resolve a web url, a filename code or an instance string into an article
+//
+func (c *CNKIDownloader) ResolveFetchTarget(s string) (*Article, error) {
+	instances, err := parseFetchTarget(s)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, instance := range instances {
+		article, err := c.GetArticle(instance)
+		if err == nil {
+			return article, nil
+		}
+
+		//
+		// the only candidate, report why it failed
+		//
+		if len(instances) == 1 {
+			return nil, err
+		}
+	}
+
+	return nil, fmt.Errorf("未找到 %s 对应的文档", s)
+}
+
+//
+// download articles by web url, filename code or instance, returns number of failures
+//
+func fetchArticles(c *CNKIDownloader, targets []string) int {
+	failed := 0
+	for _, t := range targets {
+		article, err := c.ResolveFetchTarget(t)
+		if err != nil {
+			fmt.Fprintf(color.Output, "解析 '%s' 失败 %s\n", t, color.RedString(err.Error()))
+			failed++
+			continue
+		}
+
+		color.White("下载中... %s\n", article.Information.Title)
+		path, err := c.Download(article)
+		if err != nil {
+			fmt.Fprintf(color.Output, "下载失败 %s\n", color.RedString(err.Error()))
+			failed++
+			continue
+		}
+
+		fmt.Fprintf(color.Output, "下载成功 (%s) \n", color.GreenString(path))
+	}
+	return failed
+}
+
+//
+// 'fetch' command of command line mode
+//
+func runFetchCommand(c *CNKIDownloader, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("请指定链接、文件名代码或instance")
+	}
+
+	failed := fetchArticles(c, args)
+	if failed > 0 {
+		return fmt.Errorf("%d 篇文档下载失败", failed)
+	}
+	return nil
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//
+// reference to an article of a loaded page, page and id never change
+// while the search is alive, so they can be used as a stable identifier
+//
+type articleRef struct {
+	page  int
+	id    int
+	entry *Article
+}
+
+type articleFilter struct {
+	field string
+	op    string
+	value string
+}
+
+type articleSort struct {
+	field string
+	desc  bool
+}
+
+var (
+	articleFilterPattern = regexp.MustCompile(`^\s*([A-Za-z]+)\s*(>=|<=|!=|=|>|<)\s*(.+?)\s*$`)
+
+	articleNumericFields map[string]bool = map[string]bool{
+		"year":      true,
+		"cites":     true,
+		"downloads": true,
+	}
+
+	articleTextFields map[string]bool = map[string]bool{
+		"title":  true,
+		"source": true,
+		"author": true,
+		"class":  true,
+	}
+)
+
+//
+// year of publication, 0 if unknown
+//
+func (info *ArticleInfo) GetYear() int {
+	if info.Year > 0 {
+		return info.Year
+	}
+
+	if len(info.CreateTime) >= 4 {
+		y, err := strconv.Atoi(info.CreateTime[:4])
+		if err == nil {
+			return y
+		}
+	}
+	return 0
+}
+
+//
+// numeric value of a field
+//
+func (info *ArticleInfo) numericField(field string) int {
+	switch field {
+	case "year":
+		return info.GetYear()
+	case "cites":
+		return info.RefCount
+	case "downloads":
+		return info.DownloadCount
+	}
+	return 0
+}
+
+//
+// text values of a field, authors may have many
+//
+func (info *ArticleInfo) textField(field string) []string {
+	switch field {
+	case "title":
+		return []string{info.Title}
+	case "source":
+		return []string{info.SourceName, info.SourceAlias}
+	case "author":
+		return info.Creator
+	case "class":
+		return []string{info.ClassifyCode}
+	}
+	return nil
+}
+
+//
+// parse an expression like 'year>=2018' or 'source=计算机学报'
+//
+func parseArticleFilter(expr string) (*articleFilter, error) {
+	m := articleFilterPattern.FindStringSubmatch(expr)
+	if m == nil {
+		return nil, fmt.Errorf("无效的筛选条件 '%s'", expr)
+	}
+
+	f := &articleFilter{
+		field: strings.ToLower(m[1]),
+		op:    m[2],
+		value: m[3],
+	}
+
+	if articleNumericFields[f.field] {
+		_, err := strconv.Atoi(f.value)
+		if err != nil {
+			return nil, fmt.Errorf("字段 %s 需要数值", f.field)
+		}
+	} else if articleTextFields[f.field] {
+		if f.op != "=" && f.op != "!=" {
+			return nil, fmt.Errorf("字段 %s 仅支持 = 和 !=", f.field)
+		}
+	} else {
+		return nil, fmt.Errorf("未知的字段 %s", f.field)
+	}
+
+	return f, nil
+}
+
+func (f *articleFilter) String() string {
+	return f.field + f.op + f.value
+}
+
+//
+// test an article, text fields are matched by case-insensitive substring
+//
+func (f *articleFilter) match(info *ArticleInfo) bool {
+	if articleNumericFields[f.field] {
+		v := info.numericField(f.field)
+		n, _ := strconv.Atoi(f.value)
+		switch f.op {
+		case "=":
+			return v == n
+		case "!=":
+			return v != n
+		case ">":
+			return v > n
+		case ">=":
+			return v >= n
+		case "<":
+			return v < n
+		case "<=":
+			return v <= n
+		}
+		return false
+	}
+
+	//
+	// classes are matched by code prefix or class names, e.g. class=TP3
+	//
+	if f.field == "class" {
+		if f.op == "!=" {
+			return !matchCLC(info, f.value)
+		}
+		return matchCLC(info, f.value)
+	}
+
+	found := false
+	for _, s := range info.textField(f.field) {
+		if len(s) > 0 && strings.Contains(strings.ToLower(s), strings.ToLower(f.value)) {
+			found = true
+			break
+		}
+	}
+
+	if f.op == "!=" {
+		return !found
+	}
+	return found
+}
+
+//
+// parse 'cites desc' style sort arguments
+//
+func parseArticleSort(args []string) (*articleSort, error) {
+	if len(args) == 0 || len(args) > 2 {
+		return nil, fmt.Errorf("无效的排序参数")
+	}
+
+	s := &articleSort{field: strings.ToLower(args[0]), desc: true}
+	if !articleNumericFields[s.field] && !articleTextFields[s.field] {
+		return nil, fmt.Errorf("未知的字段 %s", s.field)
+	}
+
+	if len(args) == 2 {
+		switch strings.ToLower(args[1]) {
+		case "asc":
+			s.desc = false
+		case "desc":
+			s.desc = true
+		default:
+			return nil, fmt.Errorf("排序方向应为 asc 或 desc")
+		}
+	}
+
+	return s, nil
+}
+
+func (s *articleSort) String() string {
+	if s.desc {
+		return s.field + " desc"
+	}
+	return s.field + " asc"
+}
+
+//
+// order refs, ties keep their original order
+//
+func (s *articleSort) apply(refs []articleRef) {
+	less := func(a, b *ArticleInfo) bool {
+		if articleNumericFields[s.field] {
+			return a.numericField(s.field) < b.numericField(s.field)
+		}
+		return strings.Join(a.textField(s.field), " ") < strings.Join(b.textField(s.field), " ")
+	}
+
+	sort.SliceStable(refs, func(i, j int) bool {
+		a, b := &refs[i].entry.Information, &refs[j].entry.Information
+		if s.desc {
+			return less(b, a)
+		}
+		return less(a, b)
+	})
+}
+
+//
+// make refs of a page
+//
+func pageRefs(page int, articles []Article) []articleRef {
+	refs := make([]articleRef, 0, len(articles))
+	for i := range articles {
+		refs = append(refs, articleRef{page: page, id: i + 1, entry: &articles[i]})
+	}
+	return refs
+}
+
+//
+// all pages that have been loaded by current search
+//
+func (c *CNKIDownloader) LoadedPages() []*CNKISearchResult {
+	pages := make([]*CNKISearchResult, 0)
+	if c.search_cache.result_list == nil {
+		return pages
+	}
+
+	for e := c.search_cache.result_list.Front(); e != nil; e = e.Next() {
+		pages = append(pages, e.Value.(*CNKISearchResult))
+	}
+	return pages
+}
+
+//
+// add a filter condition to current search
+//
+func (c *CNKIDownloader) AddFilter(f *articleFilter) {
+	c.search_cache.filters = append(c.search_cache.filters, f)
+}
+
+//
+// set the sort order of current search
+//
+func (c *CNKIDownloader) SetSort(s *articleSort) {
+	c.search_cache.sort = s
+}
+
+//
+// remove all filters and sorting
+//
+func (c *CNKIDownloader) ClearFilter() {
+	c.search_cache.filters = nil
+	c.search_cache.sort = nil
+}
+
+//
+// filters and sort description of current search
+//
+func (c *CNKIDownloader) FilterInfo() (filters []string, order string) {
+	for _, f := range c.search_cache.filters {
+		filters = append(filters, f.String())
+	}
+	if c.search_cache.sort != nil {
+		order = c.search_cache.sort.String()
+	}
+	return
+}
+
+//
+// filtered and sorted view over all loaded pages
+//
+func (c *CNKIDownloader) FilteredView() []articleRef {
+	view := make([]articleRef, 0)
+	for _, p := range c.LoadedPages() {
+		for _, ref := range pageRefs(p.page_index, p.current_result) {
+			matched := true
+			for _, f := range c.search_cache.filters {
+				if !f.match(&ref.entry.Information) {
+					matched = false
+					break
+				}
+			}
+			if matched {
+				view = append(view, ref)
+			}
+		}
+	}
+
+	if c.search_cache.sort != nil {
+		c.search_cache.sort.apply(view)
+	}
+	return view
+}
+
+//
+// find an article by 'ID' of current page or by 'page-ID' of any loaded page
+//
+func (c *CNKIDownloader) ResolveArticle(s string) (*articleRef, error) {
+	page := 0
+	idString := s
+	if i := strings.Index(s, "-"); i > 0 {
+		p, err := strconv.ParseInt(s[:i], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("无效的ID %s", s)
+		}
+		page, idString = int(p), s[i+1:]
+	}
+
+	id, err := strconv.ParseInt(idString, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("无效的ID %s", s)
+	}
+
+	var result *CNKISearchResult
+	if page == 0 {
+		result, err = c.CurrentPage()
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		for _, p := range c.LoadedPages() {
+			if p.page_index == page {
+				result = p
+				break
+			}
+		}
+		if result == nil {
+			return nil, fmt.Errorf("第%d页尚未加载", page)
+		}
+	}
+
+	if id < 1 || int(id) > len(result.current_result) {
+		return nil, fmt.Errorf("ID %s 超出范围", s)
+	}
+
+	return &articleRef{page: result.page_index, id: int(id), entry: &result.current_result[id-1]}, nil
+}
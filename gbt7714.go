@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	MaxCitedAuthors = 3
+)
+
+var (
+	//
+	// document type codes of GB/T 7714-2015
+	//
+	gbtDocumentTypes map[int8]string = map[int8]string{
+		SearchJournal:     "J",
+		SearchDoctorPaper: "D",
+		SearchMasterPaper: "D",
+		SearchConference:  "C",
+	}
+)
+
+//
+// check if a string contains hanzi
+//
+func containsHan(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
+
+//
+// authors of a reference, the first three are kept if there are more
+//
+func gbtAuthors(creators []string) string {
+	names := make([]string, 0, len(creators))
+	for _, c := range creators {
+		if c = strings.TrimSpace(c); len(c) > 0 {
+			names = append(names, c)
+		}
+	}
+
+	if len(names) <= MaxCitedAuthors {
+		return strings.Join(names, ", ")
+	}
+
+	etAl := "等"
+	if !containsHan(strings.Join(names, "")) {
+		etAl = "et al"
+	}
+	return strings.Join(names[:MaxCitedAuthors], ", ") + ", " + etAl
+}
+
+//
+// '01' is written as '1', others are kept
+//
+func trimIssue(issue string) string {
+	issue = strings.TrimSpace(issue)
+	if n, err := strconv.Atoi(issue); err == nil {
+		return strconv.Itoa(n)
+	}
+	return issue
+}
+
+//
+// format an article as a GB/T 7714-2015 reference
+//
+func formatGBT7714(a *Article) string {
+	info := &a.Information
+	db := a.GetDatabase()
+
+	code, ok := gbtDocumentTypes[db]
+	if !ok {
+		code = "Z"
+	}
+
+	b := new(strings.Builder)
+	if authors := gbtAuthors(info.Creator); len(authors) > 0 {
+		b.WriteString(authors + ". ")
+	}
+	fmt.Fprintf(b, "%s[%s]", strings.TrimSpace(info.Title), code)
+
+	year := ""
+	if y := info.GetYear(); y > 0 {
+		year = strconv.Itoa(y)
+	}
+
+	switch db {
+	case SearchJournal:
+		{
+			//
+			// 刊名, 年, 卷(期): 页码
+			//
+			b.WriteString(". " + info.SourceName)
+			if len(year) > 0 {
+				b.WriteString(", " + year)
+			}
+			if len(info.Volume) > 0 {
+				b.WriteString(", " + info.Volume)
+			}
+			if issue := trimIssue(info.Issue); len(issue) > 0 {
+				fmt.Fprintf(b, "(%s)", issue)
+			}
+			if len(info.Pages) > 0 {
+				b.WriteString(": " + info.Pages)
+			}
+		}
+	case SearchConference:
+		{
+			//
+			// //会议录, 年: 页码
+			//
+			if len(info.SourceName) > 0 {
+				b.WriteString("//" + info.SourceName)
+			}
+			if len(year) > 0 {
+				b.WriteString(", " + year)
+			}
+			if len(info.Pages) > 0 {
+				b.WriteString(": " + info.Pages)
+			}
+		}
+	default:
+		{
+			//
+			// 保存单位, 年
+			//
+			parts := make([]string, 0, 2)
+			if len(info.SourceName) > 0 {
+				parts = append(parts, info.SourceName)
+			}
+			if len(year) > 0 {
+				parts = append(parts, year)
+			}
+			if len(parts) > 0 {
+				b.WriteString(". " + strings.Join(parts, ", "))
+			}
+		}
+	}
+
+	b.WriteString(".")
+	return b.String()
+}
+
+//
+// write articles as a numbered GB/T 7714 reference list
+//
+func exportGBT7714(w io.Writer, articles []Article) error {
+	for i := range articles {
+		_, err := fmt.Fprintf(w, "[%d] %s\n", i+1, formatGBT7714(&articles[i]))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//
+// REPL command: CITE ID1 ID2...
+//
+func citeCommand(c *CNKIDownloader, args []string) {
+	if len(args) == 0 {
+		color.Red("输入无效")
+		return
+	}
+
+	for _, s := range args {
+		ref, err := c.ResolveArticle(s)
+		if err != nil {
+			fmt.Fprintf(color.Output, "输入无效 %s\n", color.RedString(err.Error()))
+			return
+		}
+
+		//
+		// results cached before volume and pages were requested lack them,
+		// the full record is loaded then
+		//
+		info := &ref.entry.Information
+		if ref.entry.GetDatabase() == SearchJournal && (len(info.Volume) == 0 || len(info.Pages) == 0) && !c.offline {
+			c.LoadDetail(ref)
+		}
+		fmt.Fprintf(color.Output, "%s %s\n", color.CyanString("[%s]", s), color.WhiteString(formatGBT7714(ref.entry)))
+	}
+}
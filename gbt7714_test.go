@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+//
+// a journal article from a search response is cited with volume and pages
+//
+func TestCiteSearchResult(t *testing.T) {
+	const response = `{
+		"pageSize": 20, "pageIndex": 1, "pageCount": 1, "recordCount": 1,
+		"store": [{
+			"instance": "journals:JSJX201801001",
+			"data": [
+				{"rdfProperty": "dc:title", "value": "基于深度学习的图像识别研究"},
+				{"rdfProperty": "dc:creator", "value": "张三"},
+				{"rdfProperty": "dc:creator", "value": "李四"},
+				{"rdfProperty": "dc:source", "colName": "来源", "value": "计算机学报"},
+				{"rdfProperty": "cnki:year", "value": "2018"},
+				{"rdfProperty": "cnki:issue", "value": "01"},
+				{"rdfProperty": "cnki:volume", "value": "41"},
+				{"rdfProperty": "cnki:page", "value": "1-10"}
+			]
+		}]
+	}`
+
+	fields := ""
+	c := &CNKIDownloader{
+		http_client: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			fields = r.URL.Query().Get("fields")
+			return textResponse(http.StatusOK, response), nil
+		})},
+	}
+
+	option := &searchOption{
+		filter:  searchFilterDefs[SearchBySubject],
+		databse: searchRangeDefs[SearchJournal],
+		order:   searchOrderDefs[OrderBySubject],
+	}
+	result, err := c.Search("深度学习", option, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range []string{"cnki:volume", "cnki:page"} {
+		if !strings.Contains(","+fields+",", ","+f+",") {
+			t.Errorf("search fields %q lack %s", fields, f)
+		}
+	}
+
+	articles := result.GetPageData()
+	if len(articles) != 1 {
+		t.Fatalf("got %d articles", len(articles))
+	}
+
+	want := "张三, 李四. 基于深度学习的图像识别研究[J]. 计算机学报, 2018, 41(1): 1-10."
+	if got := formatGBT7714(&articles[0]); got != want {
+		t.Errorf("citation = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,33 @@
+package pinyin
+
+// 带音标字符。
+var phoneticSymbol = map[string]string{
+	"ā": "a1",
+	"á": "a2",
+	"ǎ": "a3",
+	"à": "a4",
+	"ē": "e1",
+	"é": "e2",
+	"ě": "e3",
+	"è": "e4",
+	"ō": "o1",
+	"ó": "o2",
+	"ǒ": "o3",
+	"ò": "o4",
+	"ī": "i1",
+	"í": "i2",
+	"ǐ": "i3",
+	"ì": "i4",
+	"ū": "u1",
+	"ú": "u2",
+	"ǔ": "u3",
+	"ù": "u4",
+	"ü": "v",
+	"ǘ": "v2",
+	"ǚ": "v3",
+	"ǜ": "v4",
+	"ń": "n2",
+	"ň": "n3",
+	"ǹ": "n4",
+	"ḿ": "m2",
+}
@@ -0,0 +1,367 @@
+package main
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//
+// undirected weighted graph of items appearing together, such as co-authors
+//
+type cooccurrenceGraph struct {
+	labels  []string
+	index   map[string]int
+	weights []int
+	edges   map[[2]int]int
+}
+
+type graphEdge struct {
+	from   int
+	to     int
+	weight int
+}
+
+func newCooccurrenceGraph() *cooccurrenceGraph {
+	return &cooccurrenceGraph{
+		index: make(map[string]int),
+		edges: make(map[[2]int]int),
+	}
+}
+
+func (g *cooccurrenceGraph) node(label string) int {
+	if i, ok := g.index[label]; ok {
+		return i
+	}
+	g.labels = append(g.labels, label)
+	g.weights = append(g.weights, 0)
+	g.index[label] = len(g.labels) - 1
+	return len(g.labels) - 1
+}
+
+//
+// add items that appear together, every pair of them is linked
+//
+func (g *cooccurrenceGraph) AddGroup(items []string) {
+	ids := make([]int, 0, len(items))
+	added := make(map[int]bool)
+	for _, item := range items {
+		if item = strings.TrimSpace(item); len(item) == 0 {
+			continue
+		}
+		i := g.node(item)
+		if added[i] {
+			continue
+		}
+		added[i] = true
+		g.weights[i]++
+		ids = append(ids, i)
+	}
+
+	for x := 0; x < len(ids); x++ {
+		for y := x + 1; y < len(ids); y++ {
+			a, b := ids[x], ids[y]
+			if a > b {
+				a, b = b, a
+			}
+			g.edges[[2]int{a, b}]++
+		}
+	}
+}
+
+//
+// edges sorted by weight, heaviest first
+//
+func (g *cooccurrenceGraph) Edges() []graphEdge {
+	edges := make([]graphEdge, 0, len(g.edges))
+	for k, w := range g.edges {
+		edges = append(edges, graphEdge{k[0], k[1], w})
+	}
+
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].weight != edges[j].weight {
+			return edges[i].weight > edges[j].weight
+		}
+		if edges[i].from != edges[j].from {
+			return edges[i].from < edges[j].from
+		}
+		return edges[i].to < edges[j].to
+	})
+	return edges
+}
+
+type graphMLKey struct {
+	Id   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLNode struct {
+	Id   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string        `xml:"source,attr"`
+	Target string        `xml:"target,attr"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLGraph struct {
+	Id      string        `xml:"id,attr"`
+	EdgeDef string        `xml:"edgedefault,attr"`
+	Nodes   []graphMLNode `xml:"node"`
+	Edges   []graphMLEdge `xml:"edge"`
+}
+
+type graphMLDocument struct {
+	XMLName xml.Name     `xml:"graphml"`
+	Xmlns   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+//
+// write the graph in GraphML, nodes carry a label and a count
+//
+func (g *cooccurrenceGraph) WriteGraphML(w io.Writer) error {
+	doc := &graphMLDocument{
+		Xmlns: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{"label", "node", "label", "string"},
+			{"count", "node", "count", "int"},
+			{"weight", "edge", "weight", "int"},
+		},
+		Graph: graphMLGraph{Id: "G", EdgeDef: "undirected"},
+	}
+
+	for i, label := range g.labels {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			Id:   "n" + strconv.Itoa(i),
+			Data: []graphMLData{{"label", label}, {"count", strconv.Itoa(g.weights[i])}},
+		})
+	}
+	for _, e := range g.Edges() {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{
+			Source: "n" + strconv.Itoa(e.from),
+			Target: "n" + strconv.Itoa(e.to),
+			Data:   []graphMLData{{"weight", strconv.Itoa(e.weight)}},
+		})
+	}
+
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(doc)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+//
+// write the graph in graphviz DOT
+//
+func (g *cooccurrenceGraph) WriteDOT(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "graph cooccurrence {\n")
+	if err != nil {
+		return err
+	}
+
+	for i, label := range g.labels {
+		_, err = fmt.Fprintf(w, "  n%d [label=%s, count=%d];\n", i, strconv.Quote(label), g.weights[i])
+		if err != nil {
+			return err
+		}
+	}
+	for _, e := range g.Edges() {
+		_, err = fmt.Fprintf(w, "  n%d -- n%d [weight=%d];\n", e.from, e.to, e.weight)
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = fmt.Fprintf(w, "}\n")
+	return err
+}
+
+//
+// graph of the n most frequent items, only edges of at least minWeight are kept
+//
+func (g *cooccurrenceGraph) Top(n int, minWeight int) *cooccurrenceGraph {
+	order := make([]int, len(g.labels))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return g.weights[order[i]] > g.weights[order[j]] })
+	if n > 0 && len(order) > n {
+		order = order[:n]
+	}
+
+	top := newCooccurrenceGraph()
+	mapping := make(map[int]int)
+	for _, i := range order {
+		mapping[i] = top.node(g.labels[i])
+		top.weights[mapping[i]] = g.weights[i]
+	}
+
+	for k, w := range g.edges {
+		a, okA := mapping[k[0]]
+		b, okB := mapping[k[1]]
+		if !okA || !okB || w < minWeight {
+			continue
+		}
+		if a > b {
+			a, b = b, a
+		}
+		top.edges[[2]int{a, b}] = w
+	}
+	return top
+}
+
+//
+// group nodes by weighted label propagation, returns the cluster of every
+// node, clusters are numbered from 1 by size
+//
+func (g *cooccurrenceGraph) Clusters() []int {
+	neighbors := make([]map[int]int, len(g.labels))
+	for i := range neighbors {
+		neighbors[i] = make(map[int]int)
+	}
+	for k, w := range g.edges {
+		neighbors[k[0]][k[1]] += w
+		neighbors[k[1]][k[0]] += w
+	}
+
+	labels := make([]int, len(g.labels))
+	for i := range labels {
+		labels[i] = i
+	}
+
+	//
+	// frequent items first, so that they lead their clusters
+	//
+	order := make([]int, len(g.labels))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return g.weights[order[i]] > g.weights[order[j]] })
+
+	for round := 0; round < 100; round++ {
+		changed := false
+		for _, i := range order {
+			if len(neighbors[i]) == 0 {
+				continue
+			}
+
+			scores := make(map[int]int)
+			for j, w := range neighbors[i] {
+				scores[labels[j]] += w
+			}
+
+			best, bestScore := labels[i], scores[labels[i]]
+			for l, s := range scores {
+				if s > bestScore || s == bestScore && l < best {
+					best, bestScore = l, s
+				}
+			}
+			if best != labels[i] {
+				labels[i], changed = best, true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	//
+	// renumber by size of clusters
+	//
+	sizes := make(map[int]int)
+	for _, l := range labels {
+		sizes[l]++
+	}
+	ids := make([]int, 0, len(sizes))
+	for l := range sizes {
+		ids = append(ids, l)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		if sizes[ids[i]] != sizes[ids[j]] {
+			return sizes[ids[i]] > sizes[ids[j]]
+		}
+		return ids[i] < ids[j]
+	})
+
+	number := make(map[int]int)
+	for i, l := range ids {
+		number[l] = i + 1
+	}
+
+	clusters := make([]int, len(labels))
+	for i, l := range labels {
+		clusters[i] = number[l]
+	}
+	return clusters
+}
+
+type vosItem struct {
+	Id      int            `json:"id"`
+	Label   string         `json:"label"`
+	Cluster int            `json:"cluster"`
+	Weights map[string]int `json:"weights"`
+}
+
+type vosLink struct {
+	SourceId int `json:"source_id"`
+	TargetId int `json:"target_id"`
+	Strength int `json:"strength"`
+}
+
+//
+// write the graph as a VOSviewer JSON network, item weights are occurrences
+//
+func (g *cooccurrenceGraph) WriteVOSviewer(w io.Writer, clusters []int) error {
+	items := make([]vosItem, 0, len(g.labels))
+	for i, label := range g.labels {
+		item := vosItem{
+			Id:      i + 1,
+			Label:   label,
+			Weights: map[string]int{"Occurrences": g.weights[i]},
+		}
+		if i < len(clusters) {
+			item.Cluster = clusters[i]
+		}
+		items = append(items, item)
+	}
+
+	links := make([]vosLink, 0, len(g.edges))
+	for _, e := range g.Edges() {
+		links = append(links, vosLink{e.from + 1, e.to + 1, e.weight})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(map[string]interface{}{
+		"network": map[string]interface{}{
+			"items": items,
+			"links": links,
+		},
+	})
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+//
+// a transport answering requests by a function, for tests without network
+//
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func textResponse(code int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: code,
+		Status:     http.StatusText(code),
+		Header:     make(http.Header),
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+	}
+}
+
+//
+// use an empty configuration directory for a test
+//
+func useTempConfigDir(t *testing.T) string {
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("AppData", dir)
+	return dir
+}
+
+//
+// a journal article as returned by a search
+//
+func testJournalArticle() Article {
+	a := Article{
+		Instance: "journals:JSJX201801001",
+		Database: searchRangeDefs[SearchJournal],
+	}
+	a.Information.Title = "基于深度学习的图像识别研究"
+	a.Information.Creator = []string{"张三", "欧阳明"}
+	a.Information.SourceName = "计算机学报"
+	a.Information.Year = 2018
+	a.Information.Volume = "41"
+	a.Information.Issue = "01"
+	a.Information.Pages = "1-10"
+	a.Information.Keywords = []string{"深度学习", "图像识别"}
+	return a
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	SearchHistoryFileName = "search-history.json"
+	MaxSearchHistory      = 100
+)
+
+type searchHistoryEntry struct {
+	Keyword string             `json:"keyword"`
+	Option  storedSearchOption `json:"option"`
+	Time    time.Time          `json:"time"`
+	Hits    int                `json:"hits"`
+}
+
+func loadSearchHistory() ([]searchHistoryEntry, error) {
+	history := make([]searchHistoryEntry, 0)
+	err := loadConfigJSON(SearchHistoryFileName, &history)
+	return history, err
+}
+
+//
+// append a search to history, the oldest entries are dropped
+//
+func addSearchHistory(keyword string, option *searchOption, hits int) error {
+	history, err := loadSearchHistory()
+	if err != nil {
+		return err
+	}
+
+	history = append(history, searchHistoryEntry{
+		Keyword: keyword,
+		Option:  newStoredSearchOption(option),
+		Time:    time.Now(),
+		Hits:    hits,
+	})
+	if len(history) > MaxSearchHistory {
+		history = history[len(history)-MaxSearchHistory:]
+	}
+
+	return saveConfigJSON(SearchHistoryFileName, history)
+}
+
+//
+// option of the latest search, nil if there is no history
+//
+func lastSearchOption() *searchOption {
+	history, err := loadSearchHistory()
+	if err != nil || len(history) == 0 {
+		return nil
+	}
+	return history[len(history)-1].Option.toSearchOption()
+}
+
+//
+// get history entry by '!n'
+//
+func recallSearchHistory(s string) (*searchHistoryEntry, error) {
+	n, err := strconv.ParseInt(strings.TrimPrefix(s, "!"), 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("无效的历史记录编号 %s", s)
+	}
+
+	history, err := loadSearchHistory()
+	if err != nil {
+		return nil, err
+	}
+
+	if n < 1 || int(n) > len(history) {
+		return nil, fmt.Errorf("历史记录 %d 不存在", n)
+	}
+	return &history[n-1], nil
+}
+
+//
+// find the key of an option value, the smallest key wins if many share a value
+//
+func findOptionKey(defs map[int8]string, value string, fallback int8) int8 {
+	found := int8(0)
+	for k, v := range defs {
+		if v == value && (found == 0 || k < found) {
+			found = k
+		}
+	}
+
+	if found == 0 {
+		return fallback
+	}
+	return found
+}
+
+//
+// readable name of an option value
+//
+func optionHint(defs map[int8]string, hints map[int8]string, value string) string {
+	k := findOptionKey(defs, value, 0)
+	if k == 0 {
+		return value
+	}
+	return hints[k]
+}
+
+//
+// print search history
+//
+func printSearchHistory() {
+	history, err := loadSearchHistory()
+	if err != nil {
+		fmt.Fprintf(color.Output, "读取历史记录失败 %s\n", color.RedString(err.Error()))
+		return
+	}
+
+	if len(history) == 0 {
+		color.Yellow("暂无检索历史\n")
+		return
+	}
+
+	for i, h := range history {
+		fmt.Fprintf(color.Output, "%s: %s [%s/%s/%s] %s (%s)\n",
+			color.CyanString("!%d", i+1),
+			color.WhiteString(h.Keyword),
+			optionHint(searchFilterDefs, searchFilterHints, h.Option.Filter),
+			optionHint(searchRangeDefs, searchRangeHints, h.Option.Database),
+			optionHint(searchOrderDefs, searchOrderHints, h.Option.Order),
+			h.Time.Format("2006-01-02 15:04"),
+			color.GreenString("%d", h.Hits))
+	}
+}
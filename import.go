@@ -0,0 +1,494 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"github.com/fatih/color"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+const (
+	ImportMatchScore     = 0.9
+	ImportMinScore       = 0.6
+	ImportAmbiguousDelta = 0.05
+	ImportCandidates     = 3
+)
+
+type importStatus int
+
+const (
+	ImportFound = importStatus(iota)
+	ImportAmbiguous
+	ImportMissing
+)
+
+type importCandidate struct {
+	article Article
+	score   float64
+}
+
+type importEntry struct {
+	title      string
+	status     importStatus
+	candidates []importCandidate
+	chosen     *importCandidate
+	path       string
+	err        error
+}
+
+var (
+	bibtexTitlePattern    = regexp.MustCompile(`(?i)[,{\s]title\s*=\s*`)
+	referenceNumber       = regexp.MustCompile(`^\s*(\[\d+\]|\d+[.、)]|\(\d+\))\s*`)
+	referenceTitlePattern = regexp.MustCompile(`^(.*?)[.．]\s*(.+?)\s*\[[A-Z]{1,2}(/OL)?\]`)
+)
+
+//
+// read a brace or quote delimited BibTeX value starting at s
+//
+func readBibTeXValue(s string) string {
+	if len(s) == 0 {
+		return ""
+	}
+
+	if s[0] == '"' {
+		end := strings.Index(s[1:], `"`)
+		if end < 0 {
+			return s[1:]
+		}
+		return s[1 : end+1]
+	}
+
+	if s[0] != '{' {
+		end := strings.IndexAny(s, ",}\n")
+		if end < 0 {
+			return s
+		}
+		return s[:end]
+	}
+
+	depth := 0
+	for i, r := range s {
+		switch r {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return s[1:i]
+			}
+		}
+	}
+	return s[1:]
+}
+
+//
+// undo escapes and grouping braces of a BibTeX value
+//
+func unescapeBibTeX(s string) string {
+	s = strings.NewReplacer(`\&`, "&", `\%`, "%", `\$`, "$", `\#`, "#", `\_`, "_", `\{`, "{", `\}`, "}").Replace(s)
+	s = strings.NewReplacer("{", "", "}", "").Replace(s)
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func parseBibTeXTitles(data string) []string {
+	titles := make([]string, 0)
+	for _, loc := range bibtexTitlePattern.FindAllStringIndex(data, -1) {
+		if t := unescapeBibTeX(readBibTeXValue(data[loc[1]:])); len(t) > 0 {
+			titles = append(titles, t)
+		}
+	}
+	return titles
+}
+
+func parseRISTitles(data string) []string {
+	titles := make([]string, 0)
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "TI  -") || strings.HasPrefix(line, "T1  -") {
+			if t := strings.TrimSpace(line[5:]); len(t) > 0 {
+				titles = append(titles, t)
+			}
+		}
+	}
+	return titles
+}
+
+func parseCSVTitles(data string) ([]string, error) {
+	reader := csv.NewReader(strings.NewReader(data))
+	reader.FieldsPerRecord = -1
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	//
+	// a header names the title column, otherwise the first column is used
+	//
+	column, header := 0, false
+	for i, name := range rows[0] {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "title", "标题", "题名", "篇名":
+			column, header = i, true
+		}
+		if header {
+			break
+		}
+	}
+	if header {
+		rows = rows[1:]
+	}
+
+	titles := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if column < len(row) {
+			if t := strings.TrimSpace(row[column]); len(t) > 0 {
+				titles = append(titles, t)
+			}
+		}
+	}
+	return titles, nil
+}
+
+//
+// one title per line, numbered GB/T 7714 references are accepted as well
+//
+func parsePlainTitles(data string) []string {
+	titles := make([]string, 0)
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(line, "\ufeff"))
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = referenceNumber.ReplaceAllString(line, "")
+		if m := referenceTitlePattern.FindStringSubmatch(line); m != nil {
+			line = m[2]
+		}
+		titles = append(titles, strings.TrimSpace(line))
+	}
+	return titles
+}
+
+//
+// read titles of a reading list, the format is decided by extension or content
+//
+func readReadingList(fileName string) ([]string, error) {
+	raw, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+	data := strings.Replace(string(raw), "\r\n", "\n", -1)
+
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".bib":
+		return parseBibTeXTitles(data), nil
+	case ".ris":
+		return parseRISTitles(data), nil
+	case ".csv":
+		return parseCSVTitles(data)
+	}
+
+	trimmed := strings.TrimSpace(data)
+	switch {
+	case strings.HasPrefix(trimmed, "@"):
+		return parseBibTeXTitles(data), nil
+	case strings.HasPrefix(trimmed, "TY  -"):
+		return parseRISTitles(data), nil
+	}
+	return parsePlainTitles(data), nil
+}
+
+//
+// lower case letters and digits only, so punctuation and spaces don't matter
+//
+func normalizeTitle(s string) []rune {
+	runes := make([]rune, 0, len(s))
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			runes = append(runes, r)
+		}
+	}
+	return runes
+}
+
+//
+// similarity of two titles in [0, 1], based on edit distance
+//
+func titleSimilarity(a, b string) float64 {
+	ra, rb := normalizeTitle(a), normalizeTitle(b)
+	if len(ra) == 0 && len(rb) == 0 {
+		return 1
+	} else if len(ra) == 0 || len(rb) == 0 {
+		return 0
+	}
+
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if prev[j]+1 < cur[j] {
+				cur[j] = prev[j] + 1
+			}
+			if cur[j-1]+1 < cur[j] {
+				cur[j] = cur[j-1] + 1
+			}
+		}
+		prev, cur = cur, prev
+	}
+
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	return 1 - float64(prev[len(rb)])/float64(longest)
+}
+
+//
+// search a title and rank the results by similarity
+//
+func (c *CNKIDownloader) matchTitle(title string) *importEntry {
+	entry := &importEntry{title: title, status: ImportMissing}
+
+	option := &searchOption{
+		filter:  searchFilterDefs[SearchBySubject],
+		databse: searchRangeDefs[SearchAllDoc],
+		order:   searchOrderDefs[OrderBySubject],
+	}
+
+	result, err := c.Search(title, option, 1)
+	if err != nil {
+		entry.err = err
+		return entry
+	}
+
+	for _, a := range result.GetPageData() {
+		score := titleSimilarity(title, a.Information.Title)
+		entry.candidates = append(entry.candidates, importCandidate{a, score})
+	}
+
+	//
+	// best first
+	//
+	sort.SliceStable(entry.candidates, func(i, j int) bool {
+		return entry.candidates[i].score > entry.candidates[j].score
+	})
+	if len(entry.candidates) > ImportCandidates {
+		entry.candidates = entry.candidates[:ImportCandidates]
+	}
+
+	if len(entry.candidates) == 0 || entry.candidates[0].score < ImportMinScore {
+		return entry
+	}
+
+	best := entry.candidates[0]
+	if best.score >= ImportMatchScore &&
+		(len(entry.candidates) == 1 || best.score-entry.candidates[1].score >= ImportAmbiguousDelta) {
+		entry.status = ImportFound
+		entry.chosen = &entry.candidates[0]
+	} else {
+		entry.status = ImportAmbiguous
+	}
+	return entry
+}
+
+//
+// let the user choose one of the candidates, false if skipped
+//
+func confirmImportMatch(entry *importEntry) bool {
+	fmt.Fprintf(color.Output, "'%s' 的匹配结果不确定:\n", color.WhiteString(entry.title))
+	for i, cand := range entry.candidates {
+		fmt.Fprintf(color.Output, "\t %s: %s (%s) 相似度 %s\n",
+			color.CyanString("%d", i+1),
+			cand.article.Information.Title,
+			color.YellowString(cand.article.Information.SourceName),
+			color.GreenString("%.2f", cand.score))
+	}
+
+	for {
+		fmt.Fprintf(color.Output, "$ %s", color.CyanString("请选择 (直接回车跳过): "))
+		s := getInputString()
+		if len(s) == 0 {
+			return false
+		}
+
+		var n int
+		_, err := fmt.Sscanf(s, "%d", &n)
+		if err != nil || n < 1 || n > len(entry.candidates) {
+			color.Red("无效的选项\n")
+			continue
+		}
+
+		entry.chosen = &entry.candidates[n-1]
+		entry.status = ImportFound
+		return true
+	}
+}
+
+//
+// write a report of an import
+//
+func writeImportReport(w io.Writer, source string, entries []*importEntry) error {
+	out := bufio.NewWriter(w)
+
+	sections := []struct {
+		status importStatus
+		title  string
+	}{
+		{ImportFound, "已找到"},
+		{ImportAmbiguous, "不确定"},
+		{ImportMissing, "未找到"},
+	}
+
+	fmt.Fprintf(out, "导入报告: %s\n", source)
+	for _, sec := range sections {
+		count := 0
+		for _, e := range entries {
+			if e.status == sec.status {
+				count++
+			}
+		}
+		fmt.Fprintf(out, "\n== %s (%d) ==\n", sec.title, count)
+
+		for _, e := range entries {
+			if e.status != sec.status {
+				continue
+			}
+
+			fmt.Fprintf(out, "- %s\n", e.title)
+			switch {
+			case e.chosen != nil:
+				fmt.Fprintf(out, "    匹配: %s (%s) 相似度 %.2f [%s]\n", e.chosen.article.Information.Title,
+					e.chosen.article.Information.SourceName, e.chosen.score, e.chosen.article.Instance)
+				if e.err != nil {
+					fmt.Fprintf(out, "    下载失败: %s\n", e.err.Error())
+				} else if len(e.path) > 0 {
+					fmt.Fprintf(out, "    文件: %s\n", e.path)
+				}
+			case e.err != nil:
+				fmt.Fprintf(out, "    检索失败: %s\n", e.err.Error())
+			default:
+				for _, cand := range e.candidates {
+					fmt.Fprintf(out, "    候选: %s (%s) 相似度 %.2f [%s]\n", cand.article.Information.Title,
+						cand.article.Information.SourceName, cand.score, cand.article.Instance)
+				}
+			}
+		}
+	}
+
+	return out.Flush()
+}
+
+//
+// import a reading list: match every title, confirm ambiguous ones,
+// download the matched articles and write a report
+//
+func importReadingList(c *CNKIDownloader, fileName string, reportName string, confirm bool, download bool) error {
+	titles, err := readReadingList(fileName)
+	if err != nil {
+		return err
+	}
+	if len(titles) == 0 {
+		return fmt.Errorf("%s 中没有找到任何标题", fileName)
+	}
+
+	fmt.Fprintf(color.Output, "共 (%s) 个条目, 检索中...\n", color.GreenString("%d", len(titles)))
+
+	entries := make([]*importEntry, 0, len(titles))
+	for i, title := range titles {
+		entry := c.matchTitle(title)
+		fmt.Fprintf(color.Output, "[%d/%d] %s ", i+1, len(titles), title)
+		switch entry.status {
+		case ImportFound:
+			color.Green("(%.2f)\n", entry.chosen.score)
+		case ImportAmbiguous:
+			color.Yellow("(不确定)\n")
+		default:
+			color.Red("(未找到)\n")
+		}
+		entries = append(entries, entry)
+	}
+
+	if confirm {
+		for _, e := range entries {
+			if e.status == ImportAmbiguous {
+				confirmImportMatch(e)
+			}
+		}
+	}
+
+	if download {
+		for _, e := range entries {
+			if e.chosen == nil {
+				continue
+			}
+
+			color.White("下载中... %s\n", e.chosen.article.Information.Title)
+			e.path, e.err = c.Download(&e.chosen.article)
+			if e.err != nil {
+				fmt.Fprintf(color.Output, "下载失败 %s\n", color.RedString(e.err.Error()))
+			} else {
+				fmt.Fprintf(color.Output, "下载成功 (%s) \n", color.GreenString(e.path))
+			}
+		}
+	}
+
+	if len(reportName) == 0 {
+		reportName = strings.TrimSuffix(fileName, filepath.Ext(fileName)) + ".report.txt"
+	}
+
+	report, err := os.Create(reportName)
+	if err != nil {
+		return err
+	}
+	err = writeImportReport(report, fileName, entries)
+	if err != nil {
+		report.Close()
+		return err
+	}
+
+	fmt.Fprintf(color.Output, "导入报告已保存到 %s\n", color.GreenString(reportName))
+	return report.Close()
+}
+
+//
+// 'import' command of command line mode
+//
+func runImportCommand(c *CNKIDownloader, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	report := fs.String("report", "", "报告文件, 默认为 <输入文件名>.report.txt")
+	noConfirm := fs.Bool("no-confirm", false, "不确定的匹配不再询问, 直接跳过")
+	noDownload := fs.Bool("no-download", false, "仅匹配, 不下载")
+
+	positional, err := parseCommandFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return fmt.Errorf("请指定一个书目文件")
+	}
+
+	return importReadingList(c, positional[0], *report, !*noConfirm, !*noDownload)
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCSVTitles(t *testing.T) {
+	tests := []struct {
+		data string
+		want []string
+	}{
+		{"Title,Year\nfoo,2018\nbar,2019\n", []string{"foo", "bar"}},
+		{"Year,标题\n2018,foo\n", []string{"foo"}},
+		{"foo,2018\nbar,2019\n", []string{"foo", "bar"}},
+
+		//
+		// the first title column is used, the header is dropped once
+		//
+		{"Title,标题\nfoo,bar\nbaz,qux\n", []string{"foo", "baz"}},
+	}
+
+	for _, tt := range tests {
+		got, err := parseCSVTitles(tt.data)
+		if err != nil {
+			t.Errorf("parseCSVTitles(%q): %v", tt.data, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseCSVTitles(%q) = %v, want %v", tt.data, got, tt.want)
+		}
+	}
+}
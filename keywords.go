@@ -0,0 +1,204 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/fatih/color"
+	"io"
+	"sort"
+	"strings"
+)
+
+const (
+	DefaultNetworkSize = 100
+	MaxKeywordPairs    = 20
+	MaxClusterLabels   = 8
+)
+
+//
+// keyword co-occurrence graph of articles, keywords differing only in
+// case are taken as the same one
+//
+func keywordGraph(articles []Article) (*cooccurrenceGraph, int) {
+	g := newCooccurrenceGraph()
+	names := make(map[string]string)
+	counted := 0
+
+	for _, a := range articles {
+		keywords := make([]string, 0, len(a.Information.Keywords))
+		for _, k := range a.Information.Keywords {
+			k = strings.TrimSpace(k)
+			if len(k) == 0 {
+				continue
+			}
+
+			key := strings.ToLower(k)
+			if name, ok := names[key]; ok {
+				k = name
+			} else {
+				names[key] = k
+			}
+			keywords = append(keywords, k)
+		}
+
+		if len(keywords) > 0 {
+			g.AddGroup(keywords)
+			counted++
+		}
+	}
+	return g, counted
+}
+
+//
+// print frequent keywords, pairs and clusters of a network
+//
+func printKeywordAnalysis(full *cooccurrenceGraph, network *cooccurrenceGraph, clusters []int) {
+	frequent := make(map[string]int)
+	for i, label := range full.labels {
+		frequent[label] = full.weights[i]
+	}
+	printRanking("高频关键词", rankCounts(frequent, MaxStatsRows))
+
+	edges := full.Edges()
+	if len(edges) > MaxKeywordPairs {
+		edges = edges[:MaxKeywordPairs]
+	}
+	if len(edges) > 0 {
+		fmt.Fprintf(color.Output, "%s:\n", color.CyanString("高频共现"))
+		for _, e := range edges {
+			fmt.Fprintf(color.Output, "  %s + %s (%d)\n",
+				color.WhiteString(full.labels[e.from]), color.WhiteString(full.labels[e.to]), e.weight)
+		}
+	}
+
+	//
+	// members of clusters, frequent ones first
+	//
+	members := make(map[int][]int)
+	for i, c := range clusters {
+		members[c] = append(members[c], i)
+	}
+	ids := make([]int, 0, len(members))
+	for c := range members {
+		if len(members[c]) > 1 {
+			ids = append(ids, c)
+		}
+	}
+	sort.Ints(ids)
+
+	if len(ids) > 0 {
+		fmt.Fprintf(color.Output, "%s:\n", color.CyanString("关键词聚类"))
+	}
+	for _, c := range ids {
+		m := members[c]
+		sort.SliceStable(m, func(i, j int) bool { return network.weights[m[i]] > network.weights[m[j]] })
+
+		labels := make([]string, 0, MaxClusterLabels)
+		for _, i := range m {
+			if len(labels) == MaxClusterLabels {
+				labels = append(labels, "...")
+				break
+			}
+			labels = append(labels, network.labels[i])
+		}
+		fmt.Fprintf(color.Output, "  %s (%d): %s\n", color.GreenString("#%d", c), len(m), strings.Join(labels, ", "))
+	}
+}
+
+//
+// analyze keywords of articles, the network is exported to VOSviewer if output is given
+//
+func analyzeKeywords(articles []Article, top int, minWeight int, output string) error {
+	full, counted := keywordGraph(articles)
+	if counted == 0 {
+		return fmt.Errorf("检索结果中没有关键词")
+	}
+	fmt.Fprintf(color.Output, "\n共 (%s) 篇文献, 其中 (%s) 篇有关键词, 关键词 (%s) 个\n",
+		color.GreenString("%d", len(articles)), color.GreenString("%d", counted), color.GreenString("%d", len(full.labels)))
+
+	network := full.Top(top, minWeight)
+	clusters := network.Clusters()
+	printKeywordAnalysis(full, network, clusters)
+
+	if len(output) > 0 {
+		err := writeOutputFile(output, func(w io.Writer) error {
+			return network.WriteVOSviewer(w, clusters)
+		})
+		if err != nil {
+			return err
+		}
+		if output != "-" {
+			fmt.Fprintf(color.Output, "关键词网络已导出到 %s, 可在VOSviewer中打开\n", color.GreenString(output))
+		}
+	}
+	return nil
+}
+
+//
+// flags shared by KEYWORDS of REPL and 'keywords' of command line
+//
+func newKeywordsFlagSet(name string) (*flag.FlagSet, *int, *int, *int, *string) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	pages := fs.Int("pages", 0, "最多分析的页数, 为0时分析全部页面")
+	top := fs.Int("top", DefaultNetworkSize, "网络和聚类中保留的高频关键词数")
+	min := fs.Int("min", 1, "网络中保留的最小共现次数")
+	output := fs.String("o", "", "导出VOSviewer网络文件(JSON)")
+	return fs, pages, top, min, output
+}
+
+//
+// REPL command: KEYWORDS [-pages n] [-top n] [-min n] [-o file.json]
+//
+func keywordsCommand(c *CNKIDownloader, args []string) {
+	fs, pages, top, min, output := newKeywordsFlagSet("keywords")
+	_, err := parseCommandFlags(fs, args)
+	if err != nil {
+		fmt.Fprintf(color.Output, "输入无效 %s\n", color.RedString(err.Error()))
+		return
+	}
+
+	if c.search_cache.option == nil {
+		color.Red("无搜索结果")
+		return
+	}
+
+	articles, _, err := c.Harvest(c.search_cache.keyword, c.search_cache.option, *pages)
+	if err != nil {
+		fmt.Fprintf(color.Output, "获取检索结果失败 %s\n", color.RedString(err.Error()))
+		return
+	}
+
+	err = analyzeKeywords(articles, *top, *min, *output)
+	if err != nil {
+		fmt.Fprintf(color.Output, "分析失败 %s\n", color.RedString(err.Error()))
+	}
+}
+
+//
+// 'keywords' command of command line mode
+//
+func runKeywordsCommand(c *CNKIDownloader, args []string) error {
+	fs, pages, top, min, output := newKeywordsFlagSet("keywords")
+	filter := fs.String("by", "subject", "检索类型")
+	database := fs.String("db", "all", "检索库的范围")
+
+	positional, err := parseCommandFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		return fmt.Errorf("请指定检索的内容")
+	}
+
+	option, err := newSearchOptionByName(*filter, *database, "subject")
+	if err != nil {
+		return err
+	}
+
+	articles, _, err := c.Harvest(strings.Join(positional, " "), option, *pages)
+	if err != nil {
+		return err
+	}
+
+	return analyzeKeywords(articles, *top, *min, *output)
+}
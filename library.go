@@ -0,0 +1,335 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/fatih/color"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var (
+	libraryGroupNames []string = []string{"source", "year", "author"}
+)
+
+//
+// downloaded documents in the order of download time, numbers in
+// library commands are indexes of this list starting from 1
+//
+func libraryEntries(records map[string]*downloadRecord) []*downloadRecord {
+	entries := make([]*downloadRecord, 0, len(records))
+	for _, r := range records {
+		entries = append(entries, r)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Time.Equal(entries[j].Time) {
+			return entries[i].Instance < entries[j].Instance
+		}
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	return entries
+}
+
+//
+// find an entry by its number or instance
+//
+func findLibraryEntry(entries []*downloadRecord, s string) (int, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		if n < 1 || n > len(entries) {
+			return 0, fmt.Errorf("编号 %d 超出范围 (1-%d)", n, len(entries))
+		}
+		return n - 1, nil
+	}
+
+	for i, r := range entries {
+		if r.Instance == s {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("文库中没有 %s", s)
+}
+
+//
+// check if all terms appear in an entry, case is ignored
+//
+func (r *downloadRecord) matches(terms []string) bool {
+	info := &r.Information
+	fields := []string{r.Title, info.Title, info.EnglishTitle, info.SourceName, info.SourceAlias,
+		info.ClassifyName, info.ClassifyCode, info.Description, r.Note, r.Instance}
+	fields = append(fields, info.Creator...)
+	fields = append(fields, info.Keywords...)
+	fields = append(fields, r.Tags...)
+	text := strings.ToLower(strings.Join(fields, "\n"))
+
+	for _, t := range terms {
+		if !strings.Contains(text, strings.ToLower(t)) {
+			return false
+		}
+	}
+	return true
+}
+
+//
+// check if an entry has a tag
+//
+func (r *downloadRecord) hasTag(tag string) bool {
+	for _, t := range r.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+//
+// names of groups an entry belongs to
+//
+func (r *downloadRecord) groups(by string) []string {
+	switch by {
+	case "source":
+		if len(r.Information.SourceName) > 0 {
+			return []string{r.Information.SourceName}
+		}
+	case "year":
+		if year := r.Information.GetYear(); year > 0 {
+			return []string{strconv.Itoa(year)}
+		}
+	case "author":
+		if len(r.Information.Creator) > 0 {
+			return r.Information.Creator
+		}
+	}
+	return []string{"N/A"}
+}
+
+func printLibraryEntry(id int, r *downloadRecord) {
+	source := r.Information.SourceName
+	if len(source) == 0 {
+		source = "N/A"
+	}
+
+	title := r.Information.Title
+	if len(title) == 0 {
+		title = r.Title
+	}
+
+	tags := ""
+	if len(r.Tags) > 0 {
+		tags = " " + color.MagentaString("[%s]", strings.Join(r.Tags, ", "))
+	}
+
+	fmt.Fprintf(color.Output, "%s: %s (%s)%s\n",
+		color.CyanString("%02d", id),
+		color.WhiteString(title),
+		color.YellowString("%s", source), tags)
+}
+
+//
+// print entries grouped by source, year or author
+//
+func printLibraryGroups(entries []*downloadRecord, ids []int, by string) {
+	groups := make(map[string][]int)
+	for _, i := range ids {
+		for _, name := range entries[i].groups(by) {
+			groups[name] = append(groups[name], i)
+		}
+	}
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+
+	//
+	// latest years first, others by size of group, unknown ones at last
+	//
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == "N/A") != (names[j] == "N/A") {
+			return names[j] == "N/A"
+		}
+		if by == "year" {
+			return names[i] > names[j]
+		}
+		if len(groups[names[i]]) != len(groups[names[j]]) {
+			return len(groups[names[i]]) > len(groups[names[j]])
+		}
+		return names[i] < names[j]
+	})
+
+	for _, name := range names {
+		fmt.Fprintf(color.Output, "\n%s (%d)\n", color.GreenString(name), len(groups[name]))
+		for _, i := range groups[name] {
+			fmt.Printf("  ")
+			printLibraryEntry(i+1, entries[i])
+		}
+	}
+}
+
+//
+// print everything we know about an entry
+//
+func printLibraryDetail(id int, r *downloadRecord) {
+	info := &r.Information
+
+	title := info.Title
+	if len(title) == 0 {
+		title = r.Title
+	}
+
+	state := color.GreenString("存在")
+	if _, err := os.Stat(r.Path); err != nil {
+		state = color.RedString("不存在")
+	}
+
+	fmt.Fprintf(color.Output, "*         ID: %s\n", color.WhiteString("%d", id))
+	fmt.Fprintf(color.Output, "*   instance: %s\n", color.WhiteString(r.Instance))
+	fmt.Fprintf(color.Output, "*       标题: %s\n", color.WhiteString(title))
+	fmt.Fprintf(color.Output, "*   发表时间: %s\n", color.WhiteString(info.CreateTime))
+	fmt.Fprintf(color.Output, "*       作者: %s\n", color.GreenString(strings.Join(info.Creator, " ")))
+	fmt.Fprintf(color.Output, "*       来源: %s\n", color.GreenString("%s(%s)", info.SourceName, info.SourceAlias))
+	fmt.Fprintf(color.Output, "*     分类号: %s\n", color.WhiteString("%s.%s", info.ClassifyName, info.ClassifyCode))
+	fmt.Fprintf(color.Output, "*     关键词: %s\n", color.WhiteString(strings.Join(info.Keywords, "; ")))
+	fmt.Fprintf(color.Output, "*       标签: %s\n", color.MagentaString(strings.Join(r.Tags, ", ")))
+	fmt.Fprintf(color.Output, "*       文件: %s (%s)\n", color.WhiteString(r.Path), state)
+	fmt.Fprintf(color.Output, "*   下载时间: %s\n", color.WhiteString(r.Time.Format("2006-01-02 15:04")))
+	if len(r.Note) > 0 {
+		fmt.Fprintf(color.Output, "*       笔记: \n")
+		printWrappedText(r.Note)
+	}
+	fmt.Fprintf(color.Output, "*       摘要: \n")
+	printWrappedText(info.Description)
+}
+
+//
+// print usage of library command
+//
+func printLibraryUsage() {
+	fmt.Printf("library search <词语>...                 在已下载的文档中查找\n")
+	fmt.Printf("library list [-by %s] [-tag 标签]\n", strings.Join(libraryGroupNames, "|"))
+	fmt.Printf("                                         列出已下载的文档, 可按来源、年份或作者分组\n")
+	fmt.Printf("library show <编号|instance>             显示文档的详细信息\n")
+	fmt.Printf("library tag <编号|instance> <标签>...    添加标签\n")
+	fmt.Printf("library untag <编号|instance> <标签>...  删除标签\n")
+	fmt.Printf("library note <编号|instance> [内容]      设置笔记, 内容为空时清除\n")
+}
+
+//
+// 'library' command, works on the local download records only
+//
+func runLibraryCommand(args []string) error {
+	fs := flag.NewFlagSet("library", flag.ContinueOnError)
+	by := fs.String("by", "", "分组依据: "+strings.Join(libraryGroupNames, "|"))
+	tag := fs.String("tag", "", "仅列出带有此标签的文档")
+
+	positional, err := parseCommandFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		positional = []string{"list"}
+	}
+
+	records, err := loadDownloadRecords()
+	if err != nil {
+		return err
+	}
+	entries := libraryEntries(records)
+
+	cmd := strings.ToLower(positional[0])
+	switch cmd {
+	case "list", "search":
+		{
+			terms := positional[1:]
+			if cmd == "search" && len(terms) == 0 {
+				return fmt.Errorf("请指定查找的内容")
+			}
+
+			ids := make([]int, 0)
+			for i, r := range entries {
+				if len(*tag) > 0 && !r.hasTag(*tag) {
+					continue
+				}
+				if r.matches(terms) {
+					ids = append(ids, i)
+				}
+			}
+
+			switch strings.ToLower(*by) {
+			case "":
+				for _, i := range ids {
+					printLibraryEntry(i+1, entries[i])
+				}
+			case "source", "year", "author":
+				printLibraryGroups(entries, ids, strings.ToLower(*by))
+			default:
+				return fmt.Errorf("未知的分组依据 %s", *by)
+			}
+			fmt.Fprintf(color.Output, "共 (%s) 篇文档\n", color.GreenString("%d", len(ids)))
+		}
+	case "show":
+		{
+			if len(positional) < 2 {
+				return fmt.Errorf("请指定文档的编号或instance")
+			}
+			i, err := findLibraryEntry(entries, positional[1])
+			if err != nil {
+				return err
+			}
+			printLibraryDetail(i+1, entries[i])
+		}
+	case "tag", "untag":
+		{
+			if len(positional) < 3 {
+				return fmt.Errorf("请指定文档的编号或instance以及标签")
+			}
+			i, err := findLibraryEntry(entries, positional[1])
+			if err != nil {
+				return err
+			}
+
+			r := entries[i]
+			for _, t := range positional[2:] {
+				if cmd == "tag" {
+					if !r.hasTag(t) {
+						r.Tags = append(r.Tags, t)
+					}
+					continue
+				}
+
+				tags := r.Tags[:0]
+				for _, old := range r.Tags {
+					if !strings.EqualFold(old, t) {
+						tags = append(tags, old)
+					}
+				}
+				r.Tags = tags
+			}
+			if len(r.Tags) == 0 {
+				r.Tags = nil
+			}
+			return saveConfigJSON(DownloadRecordFileName, records)
+		}
+	case "note":
+		{
+			if len(positional) < 2 {
+				return fmt.Errorf("请指定文档的编号或instance")
+			}
+			i, err := findLibraryEntry(entries, positional[1])
+			if err != nil {
+				return err
+			}
+			entries[i].Note = strings.Join(positional[2:], " ")
+			return saveConfigJSON(DownloadRecordFileName, records)
+		}
+	case "help":
+		printLibraryUsage()
+	default:
+		printLibraryUsage()
+		return fmt.Errorf("未知的命令 library %s", positional[0])
+	}
+
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+var (
+	risReferenceTypes map[int8]string = map[int8]string{
+		SearchJournal:     "JOUR",
+		SearchDoctorPaper: "THES",
+		SearchMasterPaper: "THES",
+		SearchConference:  "CONF",
+	}
+
+	//
+	// tag that holds the source name of each reference type
+	//
+	risSourceTags map[int8]string = map[int8]string{
+		SearchJournal:     "JO",
+		SearchDoctorPaper: "PB",
+		SearchMasterPaper: "PB",
+		SearchConference:  "T2",
+	}
+)
+
+//
+// write articles as RIS records
+//
+func exportRIS(w io.Writer, articles []Article) error {
+	files := downloadedFiles(articles)
+
+	for i := range articles {
+		a := &articles[i]
+		db := a.GetDatabase()
+
+		refType, ok := risReferenceTypes[db]
+		if !ok {
+			refType = "GEN"
+		}
+
+		lines := make([]string, 0)
+		add := func(tag, value string) {
+			//
+			// a record ends at the first line break, so values must be single lines
+			//
+			value = strings.Join(strings.Fields(value), " ")
+			if len(value) > 0 {
+				lines = append(lines, fmt.Sprintf("%s  - %s", tag, value))
+			}
+		}
+
+		add("TY", refType)
+		add("TI", a.Information.Title)
+		for _, author := range a.Information.Creator {
+			add("AU", author)
+		}
+		if tag, ok := risSourceTags[db]; ok {
+			add(tag, a.Information.SourceName)
+		} else {
+			add("PB", a.Information.SourceName)
+		}
+		if year := a.Information.GetYear(); year > 0 {
+			add("PY", strconv.Itoa(year))
+		}
+		add("DA", a.Information.CreateTime)
+		add("VL", a.Information.Volume)
+		add("IS", a.Information.Issue)
+		if pages := strings.SplitN(a.Information.Pages, "-", 2); len(pages) == 2 {
+			add("SP", pages[0])
+			add("EP", pages[1])
+		} else {
+			add("SP", a.Information.Pages)
+		}
+		add("AB", a.Information.Description)
+		for _, keyword := range a.Information.Keywords {
+			add("KW", keyword)
+		}
+		add("N1", "CNKI: "+a.Instance)
+		add("L1", files[a.Instance])
+		lines = append(lines, "ER  - ")
+
+		_, err := fmt.Fprintf(w, "%s\r\n\r\n", strings.Join(lines, "\r\n"))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
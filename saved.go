@@ -0,0 +1,244 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/fatih/color"
+	"sort"
+	"time"
+)
+
+const (
+	SavedSearchFileName  = "saved-searches.json"
+	DefaultSavedRunPages = 5
+)
+
+//
+// searchOption in a form that can be persisted
+//
+type storedSearchOption struct {
+	Filter   string `json:"filter"`
+	Database string `json:"database"`
+	Order    string `json:"order"`
+	PageSize int    `json:"page_size,omitempty"`
+	Class    string `json:"class,omitempty"`
+}
+
+type savedSearch struct {
+	Name    string             `json:"name"`
+	Keyword string             `json:"keyword"`
+	Option  storedSearchOption `json:"option"`
+	Created time.Time          `json:"created"`
+	LastRun time.Time          `json:"last_run"`
+	Seen    []string           `json:"seen"`
+}
+
+func newStoredSearchOption(option *searchOption) storedSearchOption {
+	return storedSearchOption{
+		Filter:   option.filter,
+		Database: option.databse,
+		Order:    option.order,
+		PageSize: option.page_size,
+		Class:    option.class,
+	}
+}
+
+func (o storedSearchOption) toSearchOption() *searchOption {
+	return &searchOption{
+		filter:    o.Filter,
+		databse:   o.Database,
+		order:     o.Order,
+		page_size: o.PageSize,
+		class:     o.Class,
+	}
+}
+
+func loadSavedSearches() (map[string]*savedSearch, error) {
+	searches := make(map[string]*savedSearch)
+	err := loadConfigJSON(SavedSearchFileName, &searches)
+	return searches, err
+}
+
+func storeSavedSearches(searches map[string]*savedSearch) error {
+	return saveConfigJSON(SavedSearchFileName, searches)
+}
+
+//
+// save current search under a name, loaded articles are taken as seen
+//
+func (c *CNKIDownloader) SaveSearch(name string) error {
+	if c.search_cache.option == nil {
+		return fmt.Errorf("无搜索结果")
+	}
+
+	searches, err := loadSavedSearches()
+	if err != nil {
+		return err
+	}
+
+	s := &savedSearch{
+		Name:    name,
+		Keyword: c.search_cache.keyword,
+		Option:  newStoredSearchOption(c.search_cache.option),
+		Created: time.Now(),
+		LastRun: time.Now(),
+	}
+
+	//
+	// articles of all pages checked by 'saved run' are known already,
+	// or older ones would be reported as new by the first run
+	//
+	option := s.Option.toSearchOption()
+	option.fresh = true
+	articles, _, err := c.Harvest(s.Keyword, option, DefaultSavedRunPages)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range c.LoadedPages() {
+		articles = append(articles, p.current_result...)
+	}
+	for _, a := range articles {
+		if !seen[a.Instance] {
+			seen[a.Instance] = true
+			s.Seen = append(s.Seen, a.Instance)
+		}
+	}
+
+	searches[name] = s
+	return storeSavedSearches(searches)
+}
+
+//
+// run a saved search, returns articles that have never been seen before
+//
+func (c *CNKIDownloader) RunSavedSearch(name string, maxPages int) ([]Article, error) {
+	searches, err := loadSavedSearches()
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := searches[name]
+	if !ok {
+		return nil, fmt.Errorf("没有名为 %s 的检索", name)
+	}
+
+	//
+	// cached pages may miss the latest articles
+	//
+	option := s.Option.toSearchOption()
+	option.fresh = true
+	articles, _, err := c.Harvest(s.Keyword, option, maxPages)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, instance := range s.Seen {
+		seen[instance] = true
+	}
+
+	fresh := make([]Article, 0)
+	for _, a := range articles {
+		if !seen[a.Instance] {
+			seen[a.Instance] = true
+			s.Seen = append(s.Seen, a.Instance)
+			fresh = append(fresh, a)
+		}
+	}
+
+	s.LastRun = time.Now()
+	return fresh, storeSavedSearches(searches)
+}
+
+//
+// 'saved' command of command line mode
+//
+func runSavedCommand(c *CNKIDownloader, args []string) error {
+	fs := flag.NewFlagSet("saved", flag.ContinueOnError)
+	notify := fs.Bool("notify", false, "仅在有新文章时输出简洁的纯文本通知, 适合cron任务")
+	pages := fs.Int("pages", DefaultSavedRunPages, "最多检查的页数")
+
+	positional, err := parseCommandFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		positional = []string{"list"}
+	}
+
+	switch positional[0] {
+	case "list":
+		{
+			searches, err := loadSavedSearches()
+			if err != nil {
+				return err
+			}
+
+			names := make([]string, 0, len(searches))
+			for name := range searches {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				s := searches[name]
+				fmt.Fprintf(color.Output, "%s: %s (%s, 上次运行: %s, 已知 %d 篇)\n",
+					color.CyanString(name), color.WhiteString(s.Keyword), s.Option.Database,
+					s.LastRun.Format("2006-01-02 15:04"), len(s.Seen))
+			}
+		}
+	case "run":
+		{
+			if len(positional) < 2 {
+				return fmt.Errorf("请指定检索的名称")
+			}
+			name := positional[1]
+
+			fresh, err := c.RunSavedSearch(name, *pages)
+			if err != nil {
+				return err
+			}
+
+			if *notify {
+				if len(fresh) == 0 {
+					return nil
+				}
+				fmt.Printf("[%s] %d 篇新文章\n", name, len(fresh))
+				for _, a := range fresh {
+					fmt.Printf("- %s (%s, %s) %s\n", a.Information.Title, a.Information.SourceName, a.Information.CreateTime, a.Instance)
+				}
+				return nil
+			}
+
+			fmt.Fprintf(color.Output, "检索 '%s' 有 (%s) 篇新文章\n", name, color.GreenString("%d", len(fresh)))
+			for id, a := range fresh {
+				fmt.Fprintf(color.Output, "%s: %s (%s)\n",
+					color.CyanString("%02d", id+1),
+					color.WhiteString(a.Information.Title),
+					color.YellowString("%s", a.Information.SourceName))
+			}
+		}
+	case "delete":
+		{
+			if len(positional) < 2 {
+				return fmt.Errorf("请指定检索的名称")
+			}
+
+			searches, err := loadSavedSearches()
+			if err != nil {
+				return err
+			}
+			if _, ok := searches[positional[1]]; !ok {
+				return fmt.Errorf("没有名为 %s 的检索", positional[1])
+			}
+			delete(searches, positional[1])
+			return storeSavedSearches(searches)
+		}
+	default:
+		return fmt.Errorf("未知的命令 saved %s", positional[0])
+	}
+
+	return nil
+}
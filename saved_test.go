@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+//
+// a search server of pages of articles, instances[i] are of page i+1
+//
+func fakeSearchClient(t *testing.T, pages *[][]string) *http.Client {
+	return &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		page := 1
+		if p := r.URL.Query().Get("page"); len(p) > 0 {
+			page, _ = strconv.Atoi(p)
+		}
+
+		resp := cnkiSearchResponse{PageIndex: page, PageCount: len(*pages), PageSize: 2}
+		for _, instance := range (*pages)[page-1] {
+			resp.Articles = append(resp.Articles, Article{
+				Instance:   instance,
+				Arttibutes: []ArticlePropertyEntry{{Name: "dc:title", Value: "标题 " + instance}},
+			})
+			resp.RecordCount++
+		}
+
+		data, err := json.Marshal(resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return textResponse(http.StatusOK, string(data)), nil
+	})}
+}
+
+func TestSavedSearchSeedsAllCheckedPages(t *testing.T) {
+	useTempConfigDir(t)
+
+	pages := [][]string{{"journals:A", "journals:B"}, {"journals:C", "journals:D"}, {"journals:E"}}
+	cache, err := newSearchDiskCache(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &CNKIDownloader{http_client: fakeSearchClient(t, &pages), disk_cache: cache}
+
+	option := &searchOption{filter: "dc:title", databse: "/data/journals", order: "cnki:citedtime"}
+	if _, err := c.SearchFirst("深度学习", option); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.SaveSearch("dl"); err != nil {
+		t.Fatal(err)
+	}
+
+	fresh, err := c.RunSavedSearch("dl", DefaultSavedRunPages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fresh) != 0 {
+		t.Errorf("first run reports %d known articles as new", len(fresh))
+	}
+
+	//
+	// a new article shows up while the cached pages are still valid
+	//
+	pages[0] = append([]string{"journals:NEW"}, pages[0]...)
+	fresh, err = c.RunSavedSearch("dl", DefaultSavedRunPages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fresh) != 1 || fresh[0].Instance != "journals:NEW" {
+		t.Errorf("second run = %v, want journals:NEW", fresh)
+	}
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"github.com/fatih/color"
+	"os"
+	"time"
+)
+
+const (
+	TokenFileName = "token.json"
+)
+
+//
+// token of the last login, saved to be reused by the next run
+//
+type savedToken struct {
+	Username     string    `json:"username"`
+	AccessToken  string    `json:"access_token"`
+	TokenType    string    `json:"token_type"`
+	Expire       int       `json:"expires_in"`
+	Time         time.Time `json:"time"`
+	ExpiresAt    time.Time `json:"expires_at,omitempty"`
+	RefreshToken string    `json:"refresh_token"`
+}
+
+//
+// read the saved token, nil if there is none
+//
+func loadSavedToken() (*savedToken, error) {
+	t := &savedToken{}
+	err := loadConfigJSON(TokenFileName, t)
+	if err != nil {
+		return nil, err
+	}
+	if len(t.AccessToken) == 0 {
+		return nil, nil
+	}
+	return t, nil
+}
+
+//
+// save the current token, the file is readable by the owner only
+//
+func (c *CNKIDownloader) saveToken() error {
+	t := &savedToken{
+		Username:     c.username,
+		AccessToken:  c.access_token,
+		TokenType:    c.token_type,
+		Expire:       c.token_expire,
+		Time:         c.token_time,
+		ExpiresAt:    c.TokenDeadline(),
+		RefreshToken: c.refresh_token,
+	}
+	return saveConfigJSON(TokenFileName, t)
+}
+
+//
+// use a saved token, it's renewed by the refresh token if it expires soon
+//
+func (c *CNKIDownloader) restoreToken(t *savedToken) bool {
+	if !c.accounts.Select(t.Username) {
+		return false
+	}
+	c.useAccount(c.accounts.Current())
+
+	c.access_token = t.AccessToken
+	c.token_type = t.TokenType
+	c.token_expire = t.Expire
+	c.token_time = t.Time
+	c.refresh_token = t.RefreshToken
+
+	deadline := c.TokenDeadline()
+	if deadline.IsZero() || time.Now().Add(TokenRefreshMargin).Before(deadline) {
+		return true
+	}
+
+	if c.RefreshAuth() == nil {
+		return true
+	}
+	c.useAccount(c.accounts.Current())
+	return false
+}
+
+//
+// reuse the token saved last time if it's still valid, or log in, returns
+// true if the saved one is used
+//
+func (c *CNKIDownloader) Resume() (bool, error) {
+	t, err := loadSavedToken()
+	if err == nil && t != nil && c.restoreToken(t) {
+		return true, nil
+	}
+	return false, c.Login()
+}
+
+//
+// forget the token, it's removed from the disk too, requests fail until
+// logging in again by LOGIN or 'accounts use'
+//
+func (c *CNKIDownloader) Logout() error {
+	c.token_lock.Lock()
+	c.logged_out = true
+	c.access_token = ""
+	c.refresh_token = ""
+	c.token_expire = 0
+	c.token_time = time.Time{}
+	c.token_lock.Unlock()
+
+	fileName, err := getConfigFile(TokenFileName)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(fileName)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+//
+// LOGOUT: remove the saved token and end the session
+//
+func logoutCommand(c *CNKIDownloader) error {
+	err := c.Logout()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(color.Output, "已退出登录, 保存的令牌已删除, 使用 %s 重新登录\n", color.CyanString("LOGIN"))
+	return nil
+}
+
+//
+// WHOAMI: show the account and its token, the saved one if not logged in
+//
+func whoamiCommand(c *CNKIDownloader) error {
+	c.token_lock.Lock()
+	t := &savedToken{
+		Username:     c.username,
+		AccessToken:  c.access_token,
+		TokenType:    c.token_type,
+		ExpiresAt:    c.TokenDeadline(),
+		RefreshToken: c.refresh_token,
+	}
+	c.token_lock.Unlock()
+
+	source := "当前会话"
+	if len(t.AccessToken) == 0 {
+		saved, err := loadSavedToken()
+		if err != nil {
+			return err
+		}
+		if saved == nil {
+			color.Yellow("未登录")
+			return nil
+		}
+		t, source = saved, "保存的令牌"
+	}
+
+	fmt.Fprintf(color.Output, "%s: %s (%s)\n", color.CyanString("账号"), color.WhiteString(t.Username), source)
+	fmt.Fprintf(color.Output, "%s: %s\n", color.CyanString("令牌类型"), t.TokenType)
+
+	switch {
+	case t.ExpiresAt.IsZero():
+		fmt.Fprintf(color.Output, "%s: %s\n", color.CyanString("有效期"), "未知")
+	case time.Now().After(t.ExpiresAt):
+		fmt.Fprintf(color.Output, "%s: %s\n", color.CyanString("有效期"),
+			color.RedString("已于 %s 过期", t.ExpiresAt.Local().Format("2006-01-02 15:04:05")))
+	default:
+		fmt.Fprintf(color.Output, "%s: %s\n", color.CyanString("有效期"),
+			color.GreenString("至 %s (剩余 %s)", t.ExpiresAt.Local().Format("2006-01-02 15:04:05"), time.Until(t.ExpiresAt).Round(time.Second)))
+	}
+
+	refresh := "无"
+	if len(t.RefreshToken) > 0 {
+		refresh = "有"
+	}
+	fmt.Fprintf(color.Output, "%s: %s\n", color.CyanString("刷新令牌"), refresh)
+	return nil
+}
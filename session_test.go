@@ -0,0 +1,46 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestLogoutEndsSession(t *testing.T) {
+	useTempConfigDir(t)
+
+	requests := 0
+	c := &CNKIDownloader{
+		http_client: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+			requests++
+			return textResponse(http.StatusUnauthorized, ""), nil
+		})},
+		access_token:  "token",
+		token_type:    "Bearer",
+		refresh_token: "refresh",
+		token_expire:  3600,
+		token_time:    time.Now(),
+	}
+
+	err := c.Logout()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req, _ := http.NewRequest("GET", "http://api.cnki.net/test", nil)
+	_, err = c.doAuthorized(req)
+	if err != errLoggedOut {
+		t.Errorf("doAuthorized = %v, want %v", err, errLoggedOut)
+	}
+	if err = c.reauthorize(""); err != errLoggedOut {
+		t.Errorf("reauthorize = %v, want %v", err, errLoggedOut)
+	}
+	if requests != 0 {
+		t.Errorf("%d requests sent after logout", requests)
+	}
+
+	saved, err := loadSavedToken()
+	if err != nil || saved != nil {
+		t.Errorf("saved token = %v, %v, want none", saved, err)
+	}
+}
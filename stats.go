@@ -0,0 +1,407 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/mattn/go-runewidth"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	MaxStatsRows = 10
+)
+
+//
+// a table of a report
+//
+type reportTable struct {
+	title  string
+	header []string
+	rows   [][]string
+}
+
+type countBucket struct {
+	name string
+	min  int
+	max  int
+}
+
+var (
+	reportWriters map[string]func(io.Writer, []reportTable) error = map[string]func(io.Writer, []reportTable) error{
+		"text":     writeReportText,
+		"csv":      writeReportCSV,
+		"markdown": writeReportMarkdown,
+	}
+
+	citeBuckets []countBucket = []countBucket{
+		{"0", 0, 0},
+		{"1-5", 1, 5},
+		{"6-10", 6, 10},
+		{"11-50", 11, 50},
+		{"51-100", 51, 100},
+		{">100", 101, -1},
+	}
+
+	downloadBuckets []countBucket = []countBucket{
+		{"0-100", 0, 100},
+		{"101-500", 101, 500},
+		{"501-1000", 501, 1000},
+		{"1001-5000", 1001, 5000},
+		{">5000", 5001, -1},
+	}
+)
+
+func reportFormatNames() []string {
+	names := make([]string, 0, len(reportWriters))
+	for k := range reportWriters {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+//
+// write tables as aligned plain text
+//
+func writeReportText(w io.Writer, tables []reportTable) error {
+	for _, t := range tables {
+		widths := make([]int, len(t.header))
+		for _, row := range append([][]string{t.header}, t.rows...) {
+			for i, cell := range row {
+				if n := runewidth.StringWidth(cell); i < len(widths) && n > widths[i] {
+					widths[i] = n
+				}
+			}
+		}
+
+		line := func(row []string) string {
+			cells := make([]string, 0, len(row))
+			for i, cell := range row {
+				cells = append(cells, runewidth.FillRight(cell, widths[i]))
+			}
+			return strings.TrimRight(strings.Join(cells, "  "), " ")
+		}
+
+		total := 0
+		for _, n := range widths {
+			total += n + 2
+		}
+
+		_, err := fmt.Fprintf(w, "\n%s\n%s\n%s\n", t.title, line(t.header), strings.Repeat("-", total-2))
+		if err != nil {
+			return err
+		}
+		for _, row := range t.rows {
+			_, err = fmt.Fprintf(w, "%s\n", line(row))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+//
+// write tables one after another, each begins with its title
+//
+func writeReportCSV(w io.Writer, tables []reportTable) error {
+	cw := csv.NewWriter(w)
+	for i, t := range tables {
+		if i > 0 {
+			cw.Write([]string{})
+		}
+		cw.Write([]string{t.title})
+		cw.Write(t.header)
+		cw.WriteAll(t.rows)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+//
+// write tables in GitHub flavored markdown
+//
+func writeReportMarkdown(w io.Writer, tables []reportTable) error {
+	escape := strings.NewReplacer("|", "\\|", "\n", " ")
+	line := func(row []string) string {
+		cells := make([]string, 0, len(row))
+		for _, cell := range row {
+			cells = append(cells, escape.Replace(cell))
+		}
+		return "| " + strings.Join(cells, " | ") + " |\n"
+	}
+
+	for i, t := range tables {
+		if i > 0 {
+			io.WriteString(w, "\n")
+		}
+
+		sep := make([]string, len(t.header))
+		for j := range sep {
+			sep[j] = "---"
+		}
+
+		_, err := io.WriteString(w, "### "+t.title+"\n\n"+line(t.header)+line(sep))
+		if err != nil {
+			return err
+		}
+		for _, row := range t.rows {
+			_, err = io.WriteString(w, line(row))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func percent(n, total int) string {
+	if total == 0 {
+		return "0.0%"
+	}
+	return fmt.Sprintf("%.1f%%", float64(n)*100/float64(total))
+}
+
+//
+// rows of a ranking with shares of the total
+//
+func rankingRows(entries []countEntry, total int) [][]string {
+	rows := make([][]string, 0, len(entries))
+	for _, e := range entries {
+		rows = append(rows, []string{e.name, strconv.Itoa(e.count), percent(e.count, total)})
+	}
+	return rows
+}
+
+//
+// rows of a distribution over buckets
+//
+func distributionRows(values []int, buckets []countBucket) [][]string {
+	counts := make([]int, len(buckets))
+	for _, v := range values {
+		for i, b := range buckets {
+			if v >= b.min && (b.max < 0 || v <= b.max) {
+				counts[i]++
+				break
+			}
+		}
+	}
+
+	rows := make([][]string, 0, len(buckets))
+	for i, b := range buckets {
+		rows = append(rows, []string{b.name, strconv.Itoa(counts[i]), percent(counts[i], len(values))})
+	}
+	return rows
+}
+
+//
+// total, mean, median and max of values
+//
+func summarize(values []int) []string {
+	if len(values) == 0 {
+		return []string{"0", "0", "0", "0"}
+	}
+
+	sorted := append([]int(nil), values...)
+	sort.Ints(sorted)
+
+	total := 0
+	for _, v := range sorted {
+		total += v
+	}
+
+	median := float64(sorted[len(sorted)/2])
+	if len(sorted)%2 == 0 {
+		median = float64(sorted[len(sorted)/2-1]+sorted[len(sorted)/2]) / 2
+	}
+
+	return []string{
+		strconv.Itoa(total),
+		strconv.FormatFloat(float64(total)/float64(len(sorted)), 'f', 1, 64),
+		strconv.FormatFloat(median, 'f', 1, 64),
+		strconv.Itoa(sorted[len(sorted)-1]),
+	}
+}
+
+//
+// bibliometric statistics of search results
+//
+func statsReport(keyword string, records int, articles []Article) []reportTable {
+	total := len(articles)
+	years := make(map[string]int)
+	sources := make(map[string]int)
+	authors := make(map[string]int)
+	classes := make(map[string]int)
+	cites := make([]int, 0, total)
+	downloads := make([]int, 0, total)
+
+	for i := range articles {
+		info := &articles[i].Information
+
+		year := "N/A"
+		if y := info.GetYear(); y > 0 {
+			year = strconv.Itoa(y)
+		}
+		years[year]++
+
+		if len(info.SourceName) > 0 {
+			sources[info.SourceName]++
+		}
+		for _, c := range info.Creator {
+			if c = strings.TrimSpace(c); len(c) > 0 {
+				authors[c]++
+			}
+		}
+
+		//
+		// an article may have codes of several classes
+		//
+		top := make(map[string]bool)
+		for _, code := range splitCLCCodes(info.ClassifyCode) {
+			top[clcTopClass(code)] = true
+		}
+		if len(top) == 0 {
+			top["N/A"] = true
+		}
+		for k := range top {
+			classes[k]++
+		}
+
+		cites = append(cites, info.RefCount)
+		downloads = append(downloads, info.DownloadCount)
+	}
+
+	tables := make([]reportTable, 0)
+
+	tables = append(tables, reportTable{
+		title:  "概况",
+		header: []string{"项目", "值"},
+		rows: [][]string{
+			{"检索内容", keyword},
+			{"检索结果", strconv.Itoa(records)},
+			{"参与统计", strconv.Itoa(total)},
+		},
+	})
+
+	yearly := rankCounts(years, 0)
+	sort.Slice(yearly, func(i, j int) bool { return yearly[i].name < yearly[j].name })
+	tables = append(tables, reportTable{"年度发文", []string{"年份", "文献数", "占比"}, rankingRows(yearly, total)})
+
+	c, d := summarize(cites), summarize(downloads)
+	tables = append(tables, reportTable{
+		title:  "被引与下载",
+		header: []string{"指标", "被引", "下载"},
+		rows: [][]string{
+			{"总计", c[0], d[0]},
+			{"平均", c[1], d[1]},
+			{"中位数", c[2], d[2]},
+			{"最大", c[3], d[3]},
+		},
+	})
+	tables = append(tables, reportTable{"被引分布", []string{"被引次数", "文献数", "占比"}, distributionRows(cites, citeBuckets)})
+	tables = append(tables, reportTable{"下载分布", []string{"下载次数", "文献数", "占比"}, distributionRows(downloads, downloadBuckets)})
+
+	tables = append(tables, reportTable{"主要来源", []string{"来源", "文献数", "占比"}, rankingRows(rankCounts(sources, MaxStatsRows), total)})
+	tables = append(tables, reportTable{"主要作者", []string{"作者", "文献数", "占比"}, rankingRows(rankCounts(authors, MaxStatsRows), total)})
+
+	clc := reportTable{title: "中图分类", header: []string{"分类", "名称", "文献数", "占比"}}
+	for _, e := range rankCounts(classes, 0) {
+		clc.rows = append(clc.rows, []string{e.name, clcTable[e.name], strconv.Itoa(e.count), percent(e.count, total)})
+	}
+	tables = append(tables, clc)
+
+	return tables
+}
+
+//
+// write statistics of articles in a format
+//
+func writeStatsReport(fileName string, format string, keyword string, records int, articles []Article) error {
+	writer, ok := reportWriters[strings.ToLower(format)]
+	if !ok {
+		return fmt.Errorf("未知的格式 %s, 可用格式: %s", format, strings.Join(reportFormatNames(), " "))
+	}
+
+	tables := statsReport(keyword, records, articles)
+	return writeOutputFile(fileName, func(w io.Writer) error {
+		return writer(w, tables)
+	})
+}
+
+//
+// flags shared by STATS of REPL and 'stats' of command line
+//
+func newStatsFlagSet(name string) (*flag.FlagSet, *int, *string, *string) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	pages := fs.Int("pages", 0, "最多统计的页数, 为0时统计全部页面")
+	format := fs.String("format", "text", "输出格式: "+strings.Join(reportFormatNames(), "|"))
+	output := fs.String("o", "-", "输出文件, '-'为标准输出")
+	return fs, pages, format, output
+}
+
+//
+// REPL command: STATS [-pages n] [-format text|csv|markdown] [-o file]
+//
+func statsCommand(c *CNKIDownloader, args []string) {
+	fs, pages, format, output := newStatsFlagSet("stats")
+	_, err := parseCommandFlags(fs, args)
+	if err != nil {
+		fmt.Fprintf(color.Output, "输入无效 %s\n", color.RedString(err.Error()))
+		return
+	}
+
+	if c.search_cache.option == nil {
+		color.Red("无搜索结果")
+		return
+	}
+
+	articles, records, err := c.Harvest(c.search_cache.keyword, c.search_cache.option, *pages)
+	if err != nil {
+		fmt.Fprintf(color.Output, "获取检索结果失败 %s\n", color.RedString(err.Error()))
+		return
+	}
+
+	err = writeStatsReport(*output, *format, c.search_cache.keyword, records, articles)
+	if err != nil {
+		fmt.Fprintf(color.Output, "统计失败 %s\n", color.RedString(err.Error()))
+		return
+	}
+	if *output != "-" {
+		fmt.Fprintf(color.Output, "统计报告已保存到 %s\n", color.GreenString(*output))
+	}
+}
+
+//
+// 'stats' command of command line mode
+//
+func runStatsCommand(c *CNKIDownloader, args []string) error {
+	fs, pages, format, output := newStatsFlagSet("stats")
+	filter := fs.String("by", "subject", "检索类型")
+	database := fs.String("db", "all", "检索库的范围")
+
+	positional, err := parseCommandFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		return fmt.Errorf("请指定检索的内容")
+	}
+
+	option, err := newSearchOptionByName(*filter, *database, "subject")
+	if err != nil {
+		return err
+	}
+
+	keyword := strings.Join(positional, " ")
+	articles, records, err := c.Harvest(keyword, option, *pages)
+	if err != nil {
+		return err
+	}
+
+	return writeStatsReport(*output, *format, keyword, records, articles)
+}
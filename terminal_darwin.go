@@ -0,0 +1,29 @@
+package main
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+//
+// turn off the echo of a terminal, returns a function to turn it on again
+//
+func disableEcho(fd uintptr) (func(), error) {
+	var old syscall.Termios
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCGETA, uintptr(unsafe.Pointer(&old)))
+	if errno != 0 {
+		return nil, errno
+	}
+
+	state := old
+	state.Lflag &^= syscall.ECHO
+	state.Lflag |= syscall.ICANON | syscall.ISIG
+	_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCSETA, uintptr(unsafe.Pointer(&state)))
+	if errno != 0 {
+		return nil, errno
+	}
+
+	return func() {
+		syscall.Syscall(syscall.SYS_IOCTL, fd, syscall.TIOCSETA, uintptr(unsafe.Pointer(&old)))
+	}, nil
+}
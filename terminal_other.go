@@ -0,0 +1,16 @@
+//go:build !linux && !darwin && !windows
+// +build !linux,!darwin,!windows
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+//
+// echo can't be turned off on this OS
+//
+func disableEcho(fd uintptr) (func(), error) {
+	return nil, fmt.Errorf("不支持 %s 的终端", runtime.GOOS)
+}
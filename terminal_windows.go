@@ -0,0 +1,41 @@
+package main
+
+import (
+	"syscall"
+)
+
+const (
+	enableEchoInput = 0x0004
+)
+
+var procSetConsoleMode = syscall.NewLazyDLL("kernel32.dll").NewProc("SetConsoleMode")
+
+func setConsoleMode(handle syscall.Handle, mode uint32) error {
+	r, _, err := procSetConsoleMode.Call(uintptr(handle), uintptr(mode))
+	if r == 0 {
+		return err
+	}
+	return nil
+}
+
+//
+// turn off the echo of a console, returns a function to turn it on again
+//
+func disableEcho(fd uintptr) (func(), error) {
+	handle := syscall.Handle(fd)
+
+	var old uint32
+	err := syscall.GetConsoleMode(handle, &old)
+	if err != nil {
+		return nil, err
+	}
+
+	err = setConsoleMode(handle, old&^enableEchoInput)
+	if err != nil {
+		return nil, err
+	}
+
+	return func() {
+		setConsoleMode(handle, old)
+	}, nil
+}
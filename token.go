@@ -0,0 +1,226 @@
+package main
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TokenRefreshMargin = 5 * time.Minute
+)
+
+var errLoggedOut = errors.New("已退出登录, 请使用 LOGIN 重新登录")
+
+//
+// request a token of a grant, client params are signed and added here
+//
+func (c *CNKIDownloader) requestToken(param url.Values) error {
+	const (
+		appKey     = "2isdlw"
+		appId      = "cnkimdl_clcn"
+		requestURL = "http://api.cnki.net/OAuth/OAuth/Token"
+	)
+
+	signStamp := int64(time.Now().UnixNano() / 1000000)
+	sign := strconv.FormatInt(signStamp, 10)
+	enc := sha1.New()
+	enc.Write([]byte(sign + appKey))
+	secureKey := hex.EncodeToString(enc.Sum(nil))
+
+	param.Set("client_id", appId)
+	param.Set("client_secret", secureKey)
+	param.Set("sign", sign)
+
+	req, err := http.NewRequest("POST", requestURL, strings.NewReader(param.Encode()))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", "Apache-HttpClient/UNAVAILABLE (java 1.4)")
+
+	//
+	// make request
+	//
+	resp, err := c.http_client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != 200 {
+		return fmt.Errorf("Response : %s", resp.Status)
+	}
+
+	//
+	// parse data
+	//
+	result := &struct {
+		Token        string `json:"access_token"`
+		TokenType    string `json:"token_type"`
+		Expire       int    `json:"expires_in"`
+		RefreshToken string `json:"refresh_token"`
+	}{}
+
+	respData, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(respData, result)
+	if err != nil {
+		return err
+	}
+
+	if len(result.Token) == 0 {
+		return fmt.Errorf("服务器未返回访问令牌")
+	}
+
+	//
+	// set done, the refresh token is kept if a new one is not given
+	//
+	c.access_token = result.Token
+	c.token_expire = result.Expire
+	c.token_type = result.TokenType
+	c.token_time = time.Now()
+	if len(result.RefreshToken) > 0 {
+		c.refresh_token = result.RefreshToken
+	}
+
+	//
+	// the token is reused by the next run
+	//
+	err = c.saveToken()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "** 保存登录令牌失败 : %s \n", err.Error())
+	}
+
+	return nil
+}
+
+//
+// renew the token with the refresh token
+//
+func (c *CNKIDownloader) RefreshAuth() error {
+	if len(c.refresh_token) == 0 {
+		return fmt.Errorf("没有可用的刷新令牌")
+	}
+
+	param := make(url.Values)
+	param.Add("grant_type", "refresh_token")
+	param.Add("refresh_token", c.refresh_token)
+
+	return c.requestToken(param)
+}
+
+//
+// time when the token expires, zero if the server didn't tell
+//
+func (c *CNKIDownloader) TokenDeadline() time.Time {
+	if c.token_expire <= 0 || c.token_time.IsZero() {
+		return time.Time{}
+	}
+	return c.token_time.Add(time.Duration(c.token_expire) * time.Second)
+}
+
+//
+// get a new token, by the refresh token first and then by password, stale
+// is the token found invalid, nothing is done if it has been replaced
+//
+func (c *CNKIDownloader) reauthorize(stale string) error {
+	c.token_lock.Lock()
+	defer c.token_lock.Unlock()
+
+	if c.logged_out {
+		return errLoggedOut
+	}
+	if c.access_token != stale {
+		return nil
+	}
+
+	err := c.RefreshAuth()
+	if err == nil {
+		return nil
+	}
+
+	err = c.Login()
+	if err != nil {
+		return fmt.Errorf("重新登录失败: %s", err.Error())
+	}
+	return nil
+}
+
+//
+// value of the Authorization header, the token is renewed if it expires soon
+//
+func (c *CNKIDownloader) authorization() (string, string, error) {
+	c.token_lock.Lock()
+	token, deadline, loggedOut := c.access_token, c.TokenDeadline(), c.logged_out
+	c.token_lock.Unlock()
+
+	if loggedOut {
+		return "", "", errLoggedOut
+	}
+
+	if !deadline.IsZero() && time.Now().Add(TokenRefreshMargin).After(deadline) {
+		err := c.reauthorize(token)
+		if err != nil {
+			return "", "", err
+		}
+	}
+
+	c.token_lock.Lock()
+	defer c.token_lock.Unlock()
+	return fmt.Sprintf("%s %s", c.token_type, c.access_token), c.access_token, nil
+}
+
+//
+// send a request with the token, it's sent again once after authorizing
+// again if the token is rejected, or with another account if throttled
+//
+func (c *CNKIDownloader) doAuthorized(req *http.Request) (*http.Response, error) {
+	auth, token, err := c.authorization()
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", auth)
+
+	c.accounts.Count(func(u *accountUsage) { u.Requests++ })
+	resp, err := c.http_client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		resp.Body.Close()
+		err = c.reauthorize(token)
+	case http.StatusTooManyRequests:
+		resp.Body.Close()
+		err = c.rotateAccount(token)
+	default:
+		return resp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	auth, _, err = c.authorization()
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", auth)
+
+	c.accounts.Count(func(u *accountUsage) { u.Requests++ })
+	return c.http_client.Do(req)
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+)
+
+//
+// a fake of the token endpoint and an API, api answers requests with the
+// Authorization header they carry
+//
+type fakeAuthServer struct {
+	refreshFails bool
+	api          func(auth string) int
+
+	lock      sync.Mutex
+	refreshes int
+	passwords int
+	auths     []string
+}
+
+func (s *fakeAuthServer) RoundTrip(r *http.Request) (*http.Response, error) {
+	if r.URL.Path != "/OAuth/OAuth/Token" {
+		auth := r.Header.Get("Authorization")
+		s.lock.Lock()
+		s.auths = append(s.auths, auth)
+		s.lock.Unlock()
+		return textResponse(s.api(auth), ""), nil
+	}
+
+	body, _ := ioutil.ReadAll(r.Body)
+	form, _ := url.ParseQuery(string(body))
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	token := ""
+	switch form.Get("grant_type") {
+	case "refresh_token":
+		if s.refreshFails {
+			return textResponse(http.StatusBadRequest, ""), nil
+		}
+		s.refreshes++
+		token = fmt.Sprintf("refreshed-%d", s.refreshes)
+	case "password":
+		s.passwords++
+		token = fmt.Sprintf("password-%d", s.passwords)
+	}
+
+	data, _ := json.Marshal(map[string]interface{}{
+		"access_token":  token,
+		"token_type":    "Bearer",
+		"expires_in":    3600,
+		"refresh_token": "refresh-" + token,
+	})
+	return textResponse(http.StatusOK, string(data)), nil
+}
+
+func newAuthTestDownloader(t *testing.T, server *fakeAuthServer) *CNKIDownloader {
+	useTempConfigDir(t)
+	return &CNKIDownloader{
+		username:      "user",
+		password:      "pass",
+		access_token:  "old",
+		token_type:    "Bearer",
+		token_expire:  3600,
+		token_time:    time.Now(),
+		refresh_token: "refresh-old",
+		http_client:   &http.Client{Transport: server},
+	}
+}
+
+//
+// the API accepts any token but the stale one
+//
+func rejectStale(auth string) int {
+	if auth == "Bearer old" {
+		return http.StatusUnauthorized
+	}
+	return http.StatusOK
+}
+
+func doTestRequest(t *testing.T, c *CNKIDownloader) *http.Response {
+	req, _ := http.NewRequest("GET", "http://api.cnki.net/test", nil)
+	resp, err := c.doAuthorized(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp
+}
+
+func TestRetryAfterUnauthorized(t *testing.T) {
+	server := &fakeAuthServer{api: rejectStale}
+	c := newAuthTestDownloader(t, server)
+
+	resp := doTestRequest(t, c)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+	if server.refreshes != 1 || server.passwords != 0 {
+		t.Errorf("refreshes = %d, passwords = %d, want 1 and 0", server.refreshes, server.passwords)
+	}
+	if len(server.auths) != 2 || server.auths[1] != "Bearer refreshed-1" {
+		t.Errorf("requests sent with %v, want one retry with the new token", server.auths)
+	}
+}
+
+func TestRetryOnceOnly(t *testing.T) {
+	server := &fakeAuthServer{api: func(string) int { return http.StatusUnauthorized }}
+	c := newAuthTestDownloader(t, server)
+
+	resp := doTestRequest(t, c)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("status = %d, want 401", resp.StatusCode)
+	}
+	if len(server.auths) != 2 || server.refreshes != 1 {
+		t.Errorf("%d requests and %d refreshes, want 2 and 1", len(server.auths), server.refreshes)
+	}
+}
+
+func TestRefreshFallsBackToPassword(t *testing.T) {
+	server := &fakeAuthServer{api: rejectStale, refreshFails: true}
+	c := newAuthTestDownloader(t, server)
+
+	resp := doTestRequest(t, c)
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+	if server.passwords != 1 {
+		t.Errorf("passwords = %d, want 1", server.passwords)
+	}
+	if len(server.auths) != 2 || server.auths[1] != "Bearer password-1" {
+		t.Errorf("requests sent with %v, want a retry with the password token", server.auths)
+	}
+}
+
+func TestRefreshBeforeExpiry(t *testing.T) {
+	server := &fakeAuthServer{api: rejectStale}
+	c := newAuthTestDownloader(t, server)
+
+	//
+	// the token expires within the margin
+	//
+	c.token_time = time.Now().Add(-time.Duration(c.token_expire)*time.Second + TokenRefreshMargin/2)
+
+	doTestRequest(t, c)
+	if server.refreshes != 1 {
+		t.Errorf("refreshes = %d, want 1", server.refreshes)
+	}
+	if len(server.auths) != 1 || server.auths[0] != "Bearer refreshed-1" {
+		t.Errorf("requests sent with %v, want the refreshed token only", server.auths)
+	}
+}
+
+func TestConcurrentUnauthorized(t *testing.T) {
+	//
+	// both requests carry the stale token before any of them is rejected
+	//
+	arrived := sync.WaitGroup{}
+	arrived.Add(2)
+	server := &fakeAuthServer{}
+	server.api = func(auth string) int {
+		if auth == "Bearer old" {
+			arrived.Done()
+			arrived.Wait()
+		}
+		return rejectStale(auth)
+	}
+	c := newAuthTestDownloader(t, server)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req, _ := http.NewRequest("GET", "http://api.cnki.net/test", nil)
+			resp, err := c.doAuthorized(req)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusOK {
+				t.Errorf("status = %d, want 200", resp.StatusCode)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if server.refreshes+server.passwords != 1 {
+		t.Errorf("%d refreshes and %d logins, want only one", server.refreshes, server.passwords)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReadTimeoutStalledBody(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", "1024")
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("partial"))
+		w.(http.Flusher).Flush()
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	config := defaultTransportConfig()
+	config.ReadTimeout = configDuration(200 * time.Millisecond)
+	client, err := newHTTPClient(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := ioutil.ReadAll(resp.Body)
+		done <- err
+	}()
+
+	select {
+	case err = <-done:
+		if err == nil {
+			t.Error("reading a stalled body succeeded")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("reading a stalled body is not timed out")
+	}
+}
+
+func TestReadTimeoutSlowBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for i := 0; i < 5; i++ {
+			w.Write([]byte("chunk"))
+			w.(http.Flusher).Flush()
+			time.Sleep(100 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	//
+	// the whole body takes longer than the timeout, but data keeps coming
+	//
+	config := defaultTransportConfig()
+	config.ReadTimeout = configDuration(300 * time.Millisecond)
+	client, err := newHTTPClient(&config)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil || len(data) != 25 {
+		t.Errorf("ReadAll = %d bytes, %v", len(data), err)
+	}
+}
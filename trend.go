@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/csv"
+	"flag"
+	"fmt"
+	"github.com/fatih/color"
+	"github.com/mattn/go-runewidth"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultTrendFromYear = 2000
+	TrendBarWidth        = 50
+)
+
+var (
+	yearRangePattern = regexp.MustCompile(`^(\d{4})\s*[-~]\s*(\d{4})$`)
+
+	trendColors []func(string, ...interface{}) string = []func(string, ...interface{}) string{
+		color.GreenString,
+		color.YellowString,
+		color.CyanString,
+		color.MagentaString,
+		color.RedString,
+		color.BlueString,
+	}
+)
+
+//
+// number of records of a search, only one entry is requested
+//
+func (c *CNKIDownloader) CountRecords(keyword string, option *searchOption) (int, error) {
+	counting := *option
+	counting.page_size = 1
+
+	result, err := c.Search(keyword, &counting, 1)
+	if err != nil {
+		return 0, err
+	}
+	return result.GetRecordInfo(), nil
+}
+
+//
+// records of keywords per year, counts[i][j] is of keywords[i] in year from+j
+//
+func (c *CNKIDownloader) Trend(keywords []string, option *searchOption, from, to int) ([][]int, error) {
+	counts := make([][]int, len(keywords))
+	for i, keyword := range keywords {
+		counts[i] = make([]int, 0, to-from+1)
+		for year := from; year <= to; year++ {
+			yearly := *option
+			yearly.year = year
+
+			n, err := c.CountRecords(keyword, &yearly)
+			if err != nil {
+				return nil, fmt.Errorf("统计 '%s' %d年失败: %s", keyword, year, err.Error())
+			}
+			counts[i] = append(counts[i], n)
+			fmt.Printf(".")
+		}
+	}
+	fmt.Println()
+	return counts, nil
+}
+
+//
+// parse a range like '2000-2024'
+//
+func parseYearRange(s string) (int, int, bool) {
+	m := yearRangePattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return 0, 0, false
+	}
+	from, _ := strconv.Atoi(m[1])
+	to, _ := strconv.Atoi(m[2])
+	if from > to {
+		from, to = to, from
+	}
+	return from, to, true
+}
+
+//
+// print counts as bars, keywords of a year are side by side
+//
+func printTrendChart(keywords []string, counts [][]int, from int) {
+	max := 0
+	for _, row := range counts {
+		for _, n := range row {
+			if n > max {
+				max = n
+			}
+		}
+	}
+
+	width := 0
+	for _, k := range keywords {
+		if w := runewidth.StringWidth(k); w > width {
+			width = w
+		}
+	}
+
+	fmt.Println()
+	for i, k := range keywords {
+		paint := trendColors[i%len(trendColors)]
+		fmt.Fprintf(color.Output, "%s %s  ", paint("■"), k)
+	}
+	fmt.Println()
+
+	for j := range counts[0] {
+		for i, k := range keywords {
+			label := "    "
+			if i == 0 {
+				label = strconv.Itoa(from + j)
+			}
+
+			n := 0
+			if max > 0 {
+				n = (counts[i][j]*TrendBarWidth + max - 1) / max
+			}
+
+			name := ""
+			if len(keywords) > 1 {
+				name = runewidth.FillRight(k, width) + " "
+			}
+
+			paint := trendColors[i%len(trendColors)]
+			fmt.Fprintf(color.Output, "%s %s%s %d\n", label, name, paint(strings.Repeat("■", n)), counts[i][j])
+		}
+	}
+	fmt.Println()
+}
+
+//
+// write counts as CSV, a column for each keyword
+//
+func writeTrendCSV(w io.Writer, keywords []string, counts [][]int, from int) error {
+	cw := csv.NewWriter(w)
+	cw.Write(append([]string{"年份"}, keywords...))
+	for j := range counts[0] {
+		row := []string{strconv.Itoa(from + j)}
+		for i := range keywords {
+			row = append(row, strconv.Itoa(counts[i][j]))
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+//
+// 'trend' command: TREND <keyword>... [2000-2024] [-by ...] [-db ...] [-o file.csv]
+//
+func runTrendCommand(c *CNKIDownloader, args []string) error {
+	fs := flag.NewFlagSet("trend", flag.ContinueOnError)
+	filter := fs.String("by", "subject", "检索类型")
+	database := fs.String("db", "all", "检索库的范围")
+	output := fs.String("o", "", "输出CSV文件, '-'为标准输出")
+
+	positional, err := parseCommandFlags(fs, args)
+	if err != nil {
+		return err
+	}
+
+	from, to := DefaultTrendFromYear, time.Now().Year()
+	keywords := make([]string, 0)
+	for _, s := range positional {
+		if f, t, ok := parseYearRange(s); ok {
+			from, to = f, t
+			continue
+		}
+		keywords = append(keywords, s)
+	}
+	if len(keywords) == 0 {
+		return fmt.Errorf("请指定检索的内容, 多个关键词用空格分隔, 例如: TREND 深度学习 机器学习 2000-2024")
+	}
+
+	option, err := newSearchOptionByName(*filter, *database, "subject")
+	if err != nil {
+		return err
+	}
+
+	counts, err := c.Trend(keywords, option, from, to)
+	if err != nil {
+		return err
+	}
+
+	printTrendChart(keywords, counts, from)
+
+	if len(*output) > 0 {
+		err = writeOutputFile(*output, func(w io.Writer) error {
+			return writeTrendCSV(w, keywords, counts, from)
+		})
+		if err != nil {
+			return err
+		}
+		if *output != "-" {
+			fmt.Fprintf(color.Output, "趋势数据已保存到 %s\n", color.GreenString(*output))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,270 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/fatih/color"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+var (
+	documentExts map[string]bool = map[string]bool{
+		".caj": true,
+		".pdf": true,
+	}
+)
+
+//
+// documents found in directories of the library
+//
+type documentScan struct {
+	files  map[string]bool
+	sizes  map[int64][]string
+	hashes map[string]string
+}
+
+//
+// absolute and clean form of a path, used as key of scanned files
+//
+func canonicalPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.Clean(path)
+	}
+	return abs
+}
+
+//
+// collect document files in the given directories, sub directories are not visited
+//
+func scanDocuments(dirs []string) *documentScan {
+	scan := &documentScan{
+		files:  make(map[string]bool),
+		sizes:  make(map[int64][]string),
+		hashes: make(map[string]string),
+	}
+
+	for _, dir := range dirs {
+		infos, err := ioutil.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+
+		for _, info := range infos {
+			if info.IsDir() || !documentExts[strings.ToLower(filepath.Ext(info.Name()))] {
+				continue
+			}
+			path := canonicalPath(filepath.Join(dir, info.Name()))
+			if scan.files[path] {
+				continue
+			}
+			scan.files[path] = true
+			scan.sizes[info.Size()] = append(scan.sizes[info.Size()], path)
+		}
+	}
+	return scan
+}
+
+//
+// find a scanned file with the given size and hash, hashes are computed on demand
+//
+func (s *documentScan) find(size int64, hash string, skip map[string]bool) string {
+	for _, path := range s.sizes[size] {
+		if skip[path] {
+			continue
+		}
+
+		h, ok := s.hashes[path]
+		if !ok {
+			_, h, _ = fileDigest(path)
+			s.hashes[path] = h
+		}
+		if h == hash {
+			return path
+		}
+	}
+	return ""
+}
+
+//
+// download a recorded document again to its recorded place, the name is
+// changed only if the server sends the other format
+//
+func (c *CNKIDownloader) repairDocument(r *downloadRecord) error {
+	err := os.MkdirAll(filepath.Dir(r.Path), 0755)
+	if err != nil {
+		return err
+	}
+
+	color.White("下载中... %s\n", r.article().Information.Title)
+	fullName, err := c.downloadFile(r.article(), r.Path)
+	if err != nil {
+		return err
+	}
+
+	if strings.ToLower(filepath.Ext(fullName)) == ".pdf" && !isPDFDocument(fullName) {
+		s := strings.TrimSuffix(fullName, filepath.Ext(fullName)) + ".caj"
+		if os.Rename(fullName, s) == nil {
+			fullName = s
+		}
+	}
+
+	r.Path, r.Time = fullName, time.Now()
+	r.Size, r.Hash, err = fileDigest(fullName)
+	return err
+}
+
+//
+// check every recorded download, files that have been moved are found by hash,
+// missing or corrupt files are downloaded again if repair is set, returns the
+// number of problems left
+//
+func (c *CNKIDownloader) VerifyDownloads(repair bool) (int, error) {
+	records, err := loadDownloadRecords()
+	if err != nil {
+		return 0, err
+	}
+	entries := libraryEntries(records)
+
+	//
+	// files in directories where documents have been saved
+	//
+	dirs := make([]string, 0)
+	if dir, err := os.Getwd(); err == nil {
+		dirs = append(dirs, dir)
+	}
+	recorded := make(map[string]bool)
+	for _, r := range entries {
+		dirs = append(dirs, filepath.Dir(r.Path))
+		recorded[canonicalPath(r.Path)] = true
+	}
+	scan := scanDocuments(dirs)
+
+	changed, problems := false, 0
+	counts := make(map[string]int)
+
+	for id, r := range entries {
+		state, detail := "", ""
+
+		size, hash, err := fileDigest(r.Path)
+		switch {
+		case err == nil && len(r.Hash) == 0:
+			r.Size, r.Hash, changed = size, hash, true
+			state = "补充校验"
+		case err == nil && size == r.Size && hash == r.Hash:
+			state = "正常"
+		case err == nil && size != r.Size:
+			state, detail = "损坏", fmt.Sprintf("大小 %d, 应为 %d", size, r.Size)
+		case err == nil:
+			state, detail = "损坏", "校验值不符"
+		case os.IsNotExist(err):
+			state = "缺失"
+			if len(r.Hash) > 0 {
+				if path := scan.find(r.Size, r.Hash, recorded); len(path) > 0 {
+					delete(recorded, canonicalPath(r.Path))
+					recorded[path] = true
+					r.Path, changed = path, true
+					state, detail = "已移动", path
+				}
+			}
+		default:
+			state, detail = "无法读取", err.Error()
+		}
+
+		if (state == "缺失" || state == "损坏") && repair {
+			old := canonicalPath(r.Path)
+			err := c.repairDocument(r)
+			if err != nil {
+				detail = "重新下载失败 " + err.Error()
+			} else {
+				delete(recorded, old)
+				recorded[canonicalPath(r.Path)] = true
+				changed = true
+				state, detail = "已修复", r.Path
+			}
+		}
+		counts[state]++
+
+		if state == "正常" || state == "补充校验" {
+			continue
+		}
+
+		text := color.YellowString(state)
+		if state == "缺失" || state == "损坏" || state == "无法读取" {
+			text = color.RedString(state)
+			problems++
+		}
+		fmt.Fprintf(color.Output, "%s: %s [%s] %s\n", color.CyanString("%02d", id+1), color.WhiteString(r.Title), text, detail)
+	}
+
+	if changed {
+		err = saveConfigJSON(DownloadRecordFileName, records)
+		if err != nil {
+			return problems, err
+		}
+	}
+
+	//
+	// documents without a record
+	//
+	orphans := make([]string, 0)
+	for path := range scan.files {
+		if !recorded[path] {
+			orphans = append(orphans, path)
+		}
+	}
+	sort.Strings(orphans)
+
+	if len(orphans) > 0 {
+		fmt.Fprintf(color.Output, "\n没有下载记录的文件 (%s):\n", color.YellowString("%d", len(orphans)))
+		for _, path := range orphans {
+			fmt.Printf("  %s\n", path)
+		}
+	}
+
+	fmt.Fprintf(color.Output, "\n共 (%d) 条记录: 正常 %s, 已移动 %s, 已修复 %s, 缺失 %s, 损坏 %s\n", len(entries),
+		color.GreenString("%d", counts["正常"]+counts["补充校验"]),
+		color.YellowString("%d", counts["已移动"]),
+		color.YellowString("%d", counts["已修复"]),
+		color.RedString("%d", counts["缺失"]),
+		color.RedString("%d", counts["损坏"]))
+
+	return problems, nil
+}
+
+//
+// flags of 'verify', returns whether files are only checked
+//
+func parseVerifyFlags(args []string, output io.Writer) (bool, error) {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	fs.SetOutput(output)
+	noRepair := fs.Bool("no-repair", false, "只检查, 不重新下载缺失或损坏的文件")
+
+	_, err := parseCommandFlags(fs, args)
+	return *noRepair, err
+}
+
+//
+// 'verify' command
+//
+func runVerifyCommand(c *CNKIDownloader, args []string) error {
+	noRepair, err := parseVerifyFlags(args, os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	problems, err := c.VerifyDownloads(!noRepair && !c.offline)
+	if err != nil {
+		return err
+	}
+	if problems > 0 {
+		return fmt.Errorf("%d 个文件缺失或损坏", problems)
+	}
+	return nil
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/fatih/color"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	WatchListFileName      = "watchlist.json"
+	DefaultWatchCheckPages = 3
+)
+
+//
+// a journal we follow, identified by its SourceAlias such as 'JSJX'
+//
+type journalWatch struct {
+	Alias     string    `json:"alias"`
+	Name      string    `json:"name"`
+	Download  bool      `json:"download"`
+	Added     time.Time `json:"added"`
+	LastCheck time.Time `json:"last_check"`
+	Seen      []string  `json:"seen"`
+}
+
+func loadWatchList() (map[string]*journalWatch, error) {
+	watches := make(map[string]*journalWatch)
+	err := loadConfigJSON(WatchListFileName, &watches)
+	return watches, err
+}
+
+func storeWatchList(watches map[string]*journalWatch) error {
+	return saveConfigJSON(WatchListFileName, watches)
+}
+
+//
+// search option for latest articles of a journal, cached listings are
+// skipped so that new issues are found as soon as they are published
+//
+func journalSearchOption() *searchOption {
+	return &searchOption{
+		filter:  searchFilterDefs[SearchBySource],
+		databse: searchRangeDefs[SearchJournal],
+		order:   searchOrderDefs[OrderByPublishTime],
+		fresh:   true,
+	}
+}
+
+//
+// subscribe to a journal, articles published so far are taken as seen
+//
+func (c *CNKIDownloader) WatchJournal(alias string, download bool) (*journalWatch, error) {
+	alias = strings.ToUpper(strings.TrimSpace(alias))
+	if len(alias) == 0 {
+		return nil, fmt.Errorf("请指定期刊的拼音代码")
+	}
+
+	watches, err := loadWatchList()
+	if err != nil {
+		return nil, err
+	}
+
+	//
+	// pages checked later are all taken as seen, or old articles on them
+	// would be reported as new by the first check
+	//
+	articles, records, err := c.Harvest(alias, journalSearchOption(), DefaultWatchCheckPages)
+	if err != nil {
+		return nil, err
+	}
+	if records == 0 {
+		return nil, fmt.Errorf("没有找到拼音代码为 %s 的期刊", alias)
+	}
+
+	w := &journalWatch{
+		Alias:     alias,
+		Download:  download,
+		Added:     time.Now(),
+		LastCheck: time.Now(),
+	}
+	for _, a := range articles {
+		if len(w.Name) == 0 {
+			w.Name = a.Information.SourceName
+		}
+		w.Seen = append(w.Seen, a.Instance)
+	}
+
+	watches[alias] = w
+	return w, storeWatchList(watches)
+}
+
+//
+// check a journal, returns articles that have never been seen before
+//
+func (c *CNKIDownloader) CheckJournal(w *journalWatch, maxPages int) ([]Article, error) {
+	articles, _, err := c.Harvest(w.Alias, journalSearchOption(), maxPages)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	for _, instance := range w.Seen {
+		seen[instance] = true
+	}
+
+	fresh := make([]Article, 0)
+	for _, a := range articles {
+		if !seen[a.Instance] {
+			seen[a.Instance] = true
+			w.Seen = append(w.Seen, a.Instance)
+			fresh = append(fresh, a)
+		}
+	}
+
+	w.LastCheck = time.Now()
+	return fresh, nil
+}
+
+//
+// 'watch' command
+//
+func runWatchCommand(c *CNKIDownloader, args []string) error {
+	fs := flag.NewFlagSet("watch", flag.ContinueOnError)
+	download := fs.Bool("download", false, "自动下载新文章")
+	pages := fs.Int("pages", DefaultWatchCheckPages, "每种期刊最多检查的页数")
+	notify := fs.Bool("notify", false, "仅在有新文章时输出简洁的纯文本通知, 适合cron任务")
+
+	positional, err := parseCommandFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) == 0 {
+		positional = []string{"list"}
+	}
+
+	switch strings.ToLower(positional[0]) {
+	case "add":
+		{
+			if len(positional) < 2 {
+				return fmt.Errorf("请指定期刊的拼音代码, 如 JSJX")
+			}
+
+			for _, alias := range positional[1:] {
+				w, err := c.WatchJournal(alias, *download)
+				if err != nil {
+					return err
+				}
+				fmt.Fprintf(color.Output, "已关注 %s (%s)\n", color.CyanString(w.Alias), color.WhiteString(w.Name))
+			}
+		}
+	case "list":
+		{
+			watches, err := loadWatchList()
+			if err != nil {
+				return err
+			}
+
+			aliases := make([]string, 0, len(watches))
+			for alias := range watches {
+				aliases = append(aliases, alias)
+			}
+			sort.Strings(aliases)
+
+			for _, alias := range aliases {
+				w := watches[alias]
+				auto := ""
+				if w.Download {
+					auto = ", 自动下载"
+				}
+				fmt.Fprintf(color.Output, "%s: %s (上次检查: %s, 已知 %d 篇%s)\n",
+					color.CyanString(alias), color.WhiteString(w.Name),
+					w.LastCheck.Format("2006-01-02 15:04"), len(w.Seen), auto)
+			}
+		}
+	case "remove":
+		{
+			if len(positional) < 2 {
+				return fmt.Errorf("请指定期刊的拼音代码")
+			}
+
+			watches, err := loadWatchList()
+			if err != nil {
+				return err
+			}
+			for _, alias := range positional[1:] {
+				alias = strings.ToUpper(alias)
+				if _, ok := watches[alias]; !ok {
+					return fmt.Errorf("没有关注 %s", alias)
+				}
+				delete(watches, alias)
+			}
+			return storeWatchList(watches)
+		}
+	case "check":
+		{
+			watches, err := loadWatchList()
+			if err != nil {
+				return err
+			}
+
+			aliases := positional[1:]
+			if len(aliases) == 0 {
+				for alias := range watches {
+					aliases = append(aliases, alias)
+				}
+				sort.Strings(aliases)
+			}
+
+			failed := 0
+			for _, alias := range aliases {
+				w, ok := watches[strings.ToUpper(alias)]
+				if !ok {
+					return fmt.Errorf("没有关注 %s", alias)
+				}
+
+				fresh, err := c.CheckJournal(w, *pages)
+				if err != nil {
+					fmt.Fprintf(color.Output, "检查 %s 失败 %s\n", w.Alias, color.RedString(err.Error()))
+					failed++
+					continue
+				}
+
+				//
+				// state is saved before downloading, so that a failed download
+				// won't report the same articles again
+				//
+				err = storeWatchList(watches)
+				if err != nil {
+					return err
+				}
+
+				if *notify {
+					if len(fresh) > 0 {
+						fmt.Printf("[%s %s] %d 篇新文章\n", w.Alias, w.Name, len(fresh))
+						for _, a := range fresh {
+							fmt.Printf("- %s (%s) %s\n", a.Information.Title, a.Information.CreateTime, a.Instance)
+						}
+					}
+				} else {
+					fmt.Fprintf(color.Output, "%s (%s) 有 (%s) 篇新文章\n",
+						color.CyanString(w.Alias), color.WhiteString(w.Name), color.GreenString("%d", len(fresh)))
+					for id, a := range fresh {
+						fmt.Fprintf(color.Output, "%s: %s (%s)\n",
+							color.CyanString("%02d", id+1),
+							color.WhiteString(a.Information.Title),
+							color.YellowString("%s", a.Information.CreateTime))
+					}
+				}
+
+				if !w.Download && !*download {
+					continue
+				}
+				for i := range fresh {
+					color.White("下载中... %s\n", fresh[i].Information.Title)
+					path, err := c.Download(&fresh[i])
+					if err != nil {
+						fmt.Fprintf(color.Output, "下载失败 %s\n", color.RedString(err.Error()))
+						failed++
+						continue
+					}
+					fmt.Fprintf(color.Output, "下载成功 (%s) \n", color.GreenString(path))
+				}
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d 项检查或下载失败", failed)
+			}
+		}
+	default:
+		return fmt.Errorf("未知的命令 watch %s", positional[0])
+	}
+
+	return nil
+}
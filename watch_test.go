@@ -0,0 +1,57 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCheckJournalSkipsCache(t *testing.T) {
+	useTempConfigDir(t)
+
+	pages := [][]string{{"journals:JSJX201801001", "journals:JSJX201801002"}}
+	cache, err := newSearchDiskCache(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &CNKIDownloader{http_client: fakeSearchClient(t, &pages), disk_cache: cache}
+
+	w, err := c.WatchJournal("jsjx", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//
+	// a new issue right after the journal is added
+	//
+	pages[0] = append([]string{"journals:JSJX201802001"}, pages[0]...)
+	fresh, err := c.CheckJournal(w, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fresh) != 1 || fresh[0].Instance != "journals:JSJX201802001" {
+		t.Errorf("CheckJournal = %v, want the new issue", fresh)
+	}
+}
+
+func TestWatchJournalSeedsAllCheckedPages(t *testing.T) {
+	useTempConfigDir(t)
+
+	pages := [][]string{{"journals:A", "journals:B"}, {"journals:C", "journals:D"}, {"journals:E"}}
+	c := &CNKIDownloader{http_client: fakeSearchClient(t, &pages)}
+
+	w, err := c.WatchJournal("jsjx", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(w.Seen) != 5 {
+		t.Errorf("seen %v, want all 5 articles of 3 pages", w.Seen)
+	}
+
+	fresh, err := c.CheckJournal(w, DefaultWatchCheckPages)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fresh) != 0 {
+		t.Errorf("first check reports %v as new", fresh)
+	}
+}
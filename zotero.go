@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/fatih/color"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultZoteroConnectorURL = "http://127.0.0.1:23119"
+)
+
+type zoteroCreator struct {
+	FirstName   string `json:"firstName,omitempty"`
+	LastName    string `json:"lastName"`
+	FieldMode   int    `json:"fieldMode,omitempty"`
+	CreatorType string `json:"creatorType"`
+}
+
+type zoteroTag struct {
+	Tag string `json:"tag"`
+}
+
+type zoteroItem struct {
+	Id               string          `json:"id"`
+	ItemType         string          `json:"itemType"`
+	Title            string          `json:"title"`
+	Creators         []zoteroCreator `json:"creators"`
+	PublicationTitle string          `json:"publicationTitle,omitempty"`
+	ProceedingsTitle string          `json:"proceedingsTitle,omitempty"`
+	University       string          `json:"university,omitempty"`
+	ThesisType       string          `json:"thesisType,omitempty"`
+	Publisher        string          `json:"publisher,omitempty"`
+	Date             string          `json:"date,omitempty"`
+	Volume           string          `json:"volume,omitempty"`
+	Issue            string          `json:"issue,omitempty"`
+	Pages            string          `json:"pages,omitempty"`
+	AbstractNote     string          `json:"abstractNote,omitempty"`
+	Tags             []zoteroTag     `json:"tags"`
+	Extra            string          `json:"extra,omitempty"`
+	LibraryCatalog   string          `json:"libraryCatalog"`
+}
+
+var (
+	zoteroItemTypes map[int8]string = map[int8]string{
+		SearchJournal:     "journalArticle",
+		SearchDoctorPaper: "thesis",
+		SearchMasterPaper: "thesis",
+		SearchConference:  "conferencePaper",
+	}
+)
+
+//
+// convert an article to an item of zotero connector
+//
+func newZoteroItem(a *Article, id string) zoteroItem {
+	db := a.GetDatabase()
+
+	itemType, ok := zoteroItemTypes[db]
+	if !ok {
+		itemType = "document"
+	}
+
+	item := zoteroItem{
+		Id:             id,
+		ItemType:       itemType,
+		Title:          a.Information.Title,
+		Creators:       make([]zoteroCreator, 0),
+		Date:           a.Information.CreateTime,
+		Volume:         a.Information.Volume,
+		Issue:          a.Information.Issue,
+		Pages:          a.Information.Pages,
+		AbstractNote:   a.Information.Description,
+		Tags:           make([]zoteroTag, 0),
+		Extra:          "CNKI: " + a.Instance,
+		LibraryCatalog: "CNKI",
+	}
+
+	if len(item.Date) == 0 {
+		if year := a.Information.GetYear(); year > 0 {
+			item.Date = strconv.Itoa(year)
+		}
+	}
+
+	for _, c := range a.Information.Creator {
+		if family, given, ok := splitPersonName(c); ok {
+			item.Creators = append(item.Creators, zoteroCreator{FirstName: given, LastName: family, CreatorType: "author"})
+		} else if c = strings.TrimSpace(c); len(c) > 0 {
+			item.Creators = append(item.Creators, zoteroCreator{LastName: c, FieldMode: 1, CreatorType: "author"})
+		}
+	}
+
+	for _, k := range a.Information.Keywords {
+		item.Tags = append(item.Tags, zoteroTag{k})
+	}
+
+	switch db {
+	case SearchJournal:
+		item.PublicationTitle = a.Information.SourceName
+	case SearchConference:
+		item.ProceedingsTitle = a.Information.SourceName
+	case SearchDoctorPaper, SearchMasterPaper:
+		item.University = a.Information.SourceName
+		item.ThesisType = thesisGenres[db]
+	default:
+		item.Publisher = a.Information.SourceName
+	}
+
+	return item
+}
+
+type zoteroConnector struct {
+	endpoint    string
+	http_client *http.Client
+}
+
+func newZoteroConnector(endpoint string, client *http.Client) *zoteroConnector {
+	return &zoteroConnector{
+		endpoint:    strings.TrimRight(endpoint, "/"),
+		http_client: client,
+	}
+}
+
+//
+// post to an endpoint of connector
+//
+func (z *zoteroConnector) post(path string, contentType string, body []byte, header map[string]string) error {
+	req, err := http.NewRequest("POST", z.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Zotero-Connector-API-Version", "2")
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := z.http_client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	ioutil.ReadAll(resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("响应码 : %s", resp.Status)
+	}
+	return nil
+}
+
+//
+// check if zotero is running
+//
+func (z *zoteroConnector) Ping() error {
+	err := z.post("/connector/ping", "application/json", []byte("{}"), nil)
+	if err != nil {
+		return fmt.Errorf("无法连接Zotero (%s), 请确认Zotero已经启动: %s", z.endpoint, err.Error())
+	}
+	return nil
+}
+
+//
+// save articles into zotero, downloaded files are attached,
+// returns the number of attached files
+//
+func (z *zoteroConnector) Save(articles []Article) (int, error) {
+	sessionID := strconv.FormatInt(time.Now().UnixNano(), 36)
+	files := downloadedFiles(articles)
+
+	items := make([]zoteroItem, 0, len(articles))
+	for i := range articles {
+		items = append(items, newZoteroItem(&articles[i], fmt.Sprintf("%s-%d", sessionID, i)))
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"sessionID": sessionID,
+		"uri":       "http://www.cnki.net/",
+		"items":     items,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	err = z.post("/connector/saveItems", "application/json", body, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	//
+	// attachments are uploaded one by one
+	//
+	attached := 0
+	for i := range articles {
+		path, ok := files[articles[i].Instance]
+		if !ok {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return attached, err
+		}
+
+		mimeType := "application/octet-stream"
+		if strings.ToLower(filepath.Ext(path)) == ".pdf" {
+			mimeType = "application/pdf"
+		}
+
+		metadata, err := json.Marshal(map[string]string{
+			"sessionID":    sessionID,
+			"parentItemID": items[i].Id,
+			"title":        "Full Text",
+			"url":          fileURL(path),
+		})
+		if err != nil {
+			return attached, err
+		}
+
+		err = z.post("/connector/saveAttachment", mimeType, data, map[string]string{"X-Metadata": string(metadata)})
+		if err != nil {
+			return attached, fmt.Errorf("上传附件 %s 失败: %s", filepath.Base(path), err.Error())
+		}
+		attached++
+	}
+
+	return attached, nil
+}
+
+//
+// REPL command: ZOTERO [ids|all]
+//
+func zoteroCommand(c *CNKIDownloader, endpoint string, args []string) {
+	articles, err := c.SelectArticles(args)
+	if err != nil {
+		fmt.Fprintf(color.Output, "输入无效 %s\n", color.RedString(err.Error()))
+		return
+	}
+
+	z := newZoteroConnector(endpoint, c.http_client)
+	err = z.Ping()
+	if err != nil {
+		fmt.Fprintf(color.Output, "%s\n", color.RedString(err.Error()))
+		return
+	}
+
+	attached, err := z.Save(articles)
+	if err != nil {
+		fmt.Fprintf(color.Output, "保存到Zotero失败 %s\n", color.RedString(err.Error()))
+		return
+	}
+	fmt.Fprintf(color.Output, "已保存 (%s) 篇文档到Zotero, 附件 (%s) 个\n",
+		color.GreenString("%d", len(articles)), color.GreenString("%d", attached))
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+//
+// a stand-in of the Zotero connector recording requests
+//
+type fakeZotero struct {
+	lock     sync.Mutex
+	paths    []string
+	items    []map[string]interface{}
+	metadata map[string]string
+	mimeType string
+	body     []byte
+}
+
+func (z *fakeZotero) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	z.lock.Lock()
+	defer z.lock.Unlock()
+
+	z.paths = append(z.paths, r.URL.Path)
+	data, _ := ioutil.ReadAll(r.Body)
+
+	switch r.URL.Path {
+	case "/connector/ping":
+		w.WriteHeader(http.StatusOK)
+	case "/connector/saveItems":
+		payload := struct {
+			Items []map[string]interface{} `json:"items"`
+		}{}
+		if err := json.Unmarshal(data, &payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		z.items = payload.Items
+		w.WriteHeader(http.StatusCreated)
+	case "/connector/saveAttachment":
+		z.metadata = make(map[string]string)
+		json.Unmarshal([]byte(r.Header.Get("X-Metadata")), &z.metadata)
+		z.mimeType = r.Header.Get("Content-Type")
+		z.body = data
+		w.WriteHeader(http.StatusCreated)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestZoteroConnectorSave(t *testing.T) {
+	dir := useTempConfigDir(t)
+
+	fake := &fakeZotero{}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+
+	article := testJournalArticle()
+	path := filepath.Join(dir, "paper.pdf")
+	if err := ioutil.WriteFile(path, []byte("%PDF-1.4"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := addDownloadRecord(&article, path); err != nil {
+		t.Fatal(err)
+	}
+
+	z := newZoteroConnector(server.URL+"/", server.Client())
+	if err := z.Ping(); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+
+	attached, err := z.Save([]Article{article})
+	if err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if attached != 1 {
+		t.Errorf("attached = %d, want 1", attached)
+	}
+
+	want := []string{"/connector/ping", "/connector/saveItems", "/connector/saveAttachment"}
+	if len(fake.paths) != len(want) {
+		t.Fatalf("requests = %v, want %v", fake.paths, want)
+	}
+	for i := range want {
+		if fake.paths[i] != want[i] {
+			t.Errorf("request %d = %s, want %s", i, fake.paths[i], want[i])
+		}
+	}
+
+	if len(fake.items) != 1 {
+		t.Fatalf("items = %d, want 1", len(fake.items))
+	}
+	item := fake.items[0]
+	if item["itemType"] != "journalArticle" {
+		t.Errorf("itemType = %v", item["itemType"])
+	}
+	if item["publicationTitle"] != "计算机学报" {
+		t.Errorf("publicationTitle = %v", item["publicationTitle"])
+	}
+
+	creators, _ := item["creators"].([]interface{})
+	if len(creators) != 2 {
+		t.Fatalf("creators = %v", item["creators"])
+	}
+	first := creators[0].(map[string]interface{})
+	if first["lastName"] != "张" || first["firstName"] != "三" || first["creatorType"] != "author" {
+		t.Errorf("creator = %v", first)
+	}
+	second := creators[1].(map[string]interface{})
+	if second["lastName"] != "欧阳" || second["firstName"] != "明" {
+		t.Errorf("compound surname creator = %v", second)
+	}
+
+	if fake.metadata["parentItemID"] != item["id"] {
+		t.Errorf("parentItemID = %s, want %v", fake.metadata["parentItemID"], item["id"])
+	}
+	if fake.mimeType != "application/pdf" {
+		t.Errorf("Content-Type = %s", fake.mimeType)
+	}
+	if string(fake.body) != "%PDF-1.4" {
+		t.Errorf("attachment body = %q", fake.body)
+	}
+}
+
+func TestZoteroConnectorPingFails(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	z := newZoteroConnector(server.URL, http.DefaultClient)
+	if err := z.Ping(); err == nil {
+		t.Error("ping of a closed server succeeded")
+	}
+}